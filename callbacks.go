@@ -0,0 +1,327 @@
+package grm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Statement 描述一次 Set、Get 或 Delete 操作，在回调链中传递，回调可以读取和修改其中的内容
+type Statement struct {
+	Context context.Context
+	DB      *DB           // 执行操作的会话，钩子收到的也是它
+	Models  []interface{} // 每个元素的指针（如 *User），回调可以通过指针修改模型
+	Keys    []string      // 每个元素的 Key，由 grm:keys 生成，之后的回调使用修改后的 Key
+
+	// Payloads 是每个元素序列化后的值，由 Set 的 grm:serialize 生成，grm:save 直接写入这些值。
+	// 只在 String 存储模式下的完整写入时生成；部分更新、Hash 模式、带索引、唯一约束或版本号的模型，
+	// 以及带 DeletedAt 的模型的 Create 和 Update 需要与已存储的记录合并，在事务中编码，此时为 nil
+	Payloads [][]byte
+	TTL      time.Duration // Set 的过期时间；Get 时为读取后刷新的过期时间，为 0 时不刷新
+
+	// Error 是操作的错误。回调设置 Error 后，之后的回调不再执行；
+	// 为 *PartialError 时部分元素已经成功，之后的回调仍会执行（如对成功的元素调用 AfterSave）
+	Error error
+
+	schema   *schema
+	elements []reflect.Value
+	cfg      *setConfig
+	tx       *Tx // 在 Transaction 中执行时不为空
+}
+
+// statement 创建操作 elements 的 Statement
+func (db *DB) statement(ctx context.Context, s *schema, elements []reflect.Value) *Statement {
+	models := make([]interface{}, len(elements))
+	for i, elem := range elements {
+		models[i] = elem.Addr().Interface()
+	}
+	return &Statement{Context: ctx, DB: db, Models: models, schema: s, elements: elements, cfg: &setConfig{}}
+}
+
+// serializable 判断 Set 是否直接写入 Payloads，见 Statement.Payloads 的说明
+func (stmt *Statement) serializable() bool {
+	return !stmt.DB.partial() && !watched(stmt.schema, stmt.cfg) && stmt.DB.storageMode(stmt.schema) == StorageString
+}
+
+// callback 是回调链中一个具名的回调
+type callback struct {
+	name string
+	fn   func(*Statement)
+}
+
+// Processor 是一种操作（Set、Get 或 Delete）的回调链，回调按顺序执行
+type Processor struct {
+	mu        sync.RWMutex
+	callbacks []*callback
+}
+
+// Callbacks 是 DB 的回调注册表，由 Open 创建的 DB 及其所有会话共享
+//
+//	db.Callback().Set().Before("grm:serialize").Register("tenant:scope", func(stmt *grm.Statement) { ... })
+type Callbacks struct {
+	set    *Processor
+	get    *Processor
+	delete *Processor
+}
+
+// Callback 返回 DB 的回调注册表
+func (db *DB) Callback() *Callbacks {
+	return db.callbacks
+}
+
+// Set 返回 Set 的回调链，Create、Update 和 Updates 同样经过该回调链。默认回调依次为
+// grm:before_save、grm:generate_ids、grm:timestamps、grm:keys、grm:serialize、grm:save、grm:after_save
+func (cs *Callbacks) Set() *Processor {
+	return cs.set
+}
+
+// Get 返回 Get 的回调链，默认回调依次为 grm:keys、grm:query、grm:after_find
+func (cs *Callbacks) Get() *Processor {
+	return cs.get
+}
+
+// Delete 返回 Delete 的回调链，默认回调依次为 grm:before_delete、grm:keys、grm:delete、grm:after_delete
+func (cs *Callbacks) Delete() *Processor {
+	return cs.delete
+}
+
+// Position 指定新回调在回调链中的位置
+type Position struct {
+	p      *Processor
+	target string
+	offset int // 0 表示插入到 target 之前，1 表示之后
+}
+
+// Before 返回位于回调 name 之前的位置
+func (p *Processor) Before(name string) *Position {
+	return &Position{p: p, target: name}
+}
+
+// After 返回位于回调 name 之后的位置
+func (p *Processor) After(name string) *Position {
+	return &Position{p: p, target: name, offset: 1}
+}
+
+// Register 在该位置注册回调，name 不能与已有的回调重复
+func (pos *Position) Register(name string, fn func(*Statement)) error {
+	return pos.p.update(func(cbs []*callback) ([]*callback, error) {
+		i := indexOf(cbs, pos.target)
+		if i < 0 {
+			return nil, fmt.Errorf("callback %q not found", pos.target)
+		}
+		return insert(cbs, i+pos.offset, &callback{name: name, fn: fn})
+	})
+}
+
+// Register 在回调链末尾注册回调，name 不能与已有的回调重复
+func (p *Processor) Register(name string, fn func(*Statement)) error {
+	return p.update(func(cbs []*callback) ([]*callback, error) {
+		return insert(cbs, len(cbs), &callback{name: name, fn: fn})
+	})
+}
+
+// Replace 替换回调 name 的实现，位置不变，可用于替换默认回调（如 grm:timestamps）
+func (p *Processor) Replace(name string, fn func(*Statement)) error {
+	return p.update(func(cbs []*callback) ([]*callback, error) {
+		i := indexOf(cbs, name)
+		if i < 0 {
+			return nil, fmt.Errorf("callback %q not found", name)
+		}
+		cbs[i] = &callback{name: name, fn: fn}
+		return cbs, nil
+	})
+}
+
+// Remove 移除回调 name
+func (p *Processor) Remove(name string) error {
+	return p.update(func(cbs []*callback) ([]*callback, error) {
+		i := indexOf(cbs, name)
+		if i < 0 {
+			return nil, fmt.Errorf("callback %q not found", name)
+		}
+		return append(cbs[:i], cbs[i+1:]...), nil
+	})
+}
+
+// update 在回调链的副本上修改后替换，执行中的操作不受影响
+func (p *Processor) update(fn func([]*callback) ([]*callback, error)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cbs, err := fn(append([]*callback(nil), p.callbacks...))
+	if err != nil {
+		return err
+	}
+	p.callbacks = cbs
+	return nil
+}
+
+// execute 依次执行回调，返回 stmt.Error
+func (p *Processor) execute(stmt *Statement) error {
+	p.mu.RLock()
+	cbs := p.callbacks
+	p.mu.RUnlock()
+
+	for _, cb := range cbs {
+		cb.fn(stmt)
+		if _, partial := stmt.Error.(*PartialError); stmt.Error != nil && !partial {
+			break
+		}
+	}
+	return stmt.Error
+}
+
+// indexOf 返回回调 name 的位置，不存在时返回 -1
+func indexOf(cbs []*callback, name string) int {
+	for i, cb := range cbs {
+		if cb.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// insert 将回调插入到位置 i
+func insert(cbs []*callback, i int, cb *callback) ([]*callback, error) {
+	if indexOf(cbs, cb.name) >= 0 {
+		return nil, fmt.Errorf("callback %q already registered", cb.name)
+	}
+	cbs = append(cbs, nil)
+	copy(cbs[i+1:], cbs[i:])
+	cbs[i] = cb
+	return cbs, nil
+}
+
+// newCallbacks 创建带有默认回调的注册表
+func newCallbacks() *Callbacks {
+	cs := &Callbacks{set: &Processor{}, get: &Processor{}, delete: &Processor{}}
+
+	cs.set.Register("grm:before_save", beforeSaveCallback)
+	cs.set.Register("grm:generate_ids", generateIDsCallback)
+	cs.set.Register("grm:timestamps", timestampsCallback)
+	cs.set.Register("grm:keys", keysCallback)
+	cs.set.Register("grm:serialize", serializeCallback)
+	cs.set.Register("grm:save", saveCallback)
+	cs.set.Register("grm:after_save", afterSaveCallback)
+
+	cs.get.Register("grm:keys", keysCallback)
+	cs.get.Register("grm:query", queryCallback)
+	cs.get.Register("grm:after_find", afterFindCallback)
+
+	cs.delete.Register("grm:before_delete", beforeDeleteCallback)
+	cs.delete.Register("grm:keys", keysCallback)
+	cs.delete.Register("grm:delete", deleteCallback)
+	cs.delete.Register("grm:after_delete", afterDeleteCallback)
+	return cs
+}
+
+// beforeSaveCallback 调用 BeforeSave 钩子，Create 时再调用 BeforeCreate 钩子
+func beforeSaveCallback(stmt *Statement) {
+	stmt.Error = stmt.DB.callHooks(stmt.Context, stmt.schema, hookBeforeSave, stmt.elements)
+	if stmt.Error == nil && stmt.cfg.mode == writeCreate {
+		stmt.Error = stmt.DB.callHooks(stmt.Context, stmt.schema, hookBeforeCreate, stmt.elements)
+	}
+}
+
+// generateIDsCallback 为主键为零值的元素生成主键，Update 和部分更新不生成
+func generateIDsCallback(stmt *Statement) {
+	if stmt.cfg.mode != writeUpdate && !stmt.DB.partial() {
+		stmt.Error = stmt.DB.generateIDs(stmt.Context, stmt.schema, stmt.elements)
+	}
+}
+
+// timestampsCallback 维护 CreatedAt 和 UpdatedAt。部分更新只设置 UpdatedAt，并将其加入写入的字段
+func timestampsCallback(stmt *Statement) {
+	if !stmt.DB.partial() {
+		for _, elem := range stmt.elements {
+			updateTimestamps(elem)
+		}
+		return
+	}
+
+	f := stmt.schema.lookUpField("UpdatedAt")
+	if f == nil || f.Type != timeType {
+		return
+	}
+	now := reflect.ValueOf(time.Now())
+	for _, elem := range stmt.elements {
+		elem.FieldByIndex(f.Index).Set(now)
+	}
+	stmt.DB = stmt.DB.Select(append(stmt.DB.selects[:len(stmt.DB.selects):len(stmt.DB.selects)], f.Name)...)
+}
+
+// keysCallback 生成每个元素的 Key
+func keysCallback(stmt *Statement) {
+	stmt.Keys, stmt.Error = stmt.DB.getKeys(stmt.elements)
+}
+
+// serializeCallback 使用 DB 配置的 Serializer 序列化每个元素
+func serializeCallback(stmt *Statement) {
+	if !stmt.serializable() {
+		return
+	}
+
+	stmt.Payloads = make([][]byte, len(stmt.Models))
+	for i, model := range stmt.Models {
+		if stmt.Payloads[i], stmt.Error = stmt.DB.serializer.Marshal(model); stmt.Error != nil {
+			return
+		}
+	}
+}
+
+// saveCallback 写入记录，在 Transaction 中执行时加入事务
+func saveCallback(stmt *Statement) {
+	if stmt.tx != nil {
+		stmt.Error = stmt.tx.queueSet(stmt)
+		return
+	}
+	stmt.Error = stmt.DB.save(stmt)
+}
+
+// afterSaveCallback 对写入成功的元素调用 AfterSave 钩子，在 Transaction 中执行时在提交后调用
+func afterSaveCallback(stmt *Statement) {
+	if stmt.tx != nil {
+		if stmt.Error == nil {
+			stmt.tx.afterCommit(stmt.schema, hookAfterSave, stmt.elements)
+		}
+		return
+	}
+	stmt.Error = stmt.DB.callAfterHooks(stmt.Context, stmt.schema, hookAfterSave, stmt.elements, stmt.Keys, stmt.Error)
+}
+
+// queryCallback 读取记录，已软删除的记录视为不存在
+func queryCallback(stmt *Statement) {
+	stmt.Error = stmt.DB.query(stmt)
+}
+
+// afterFindCallback 对读取成功的元素调用 AfterFind 钩子
+func afterFindCallback(stmt *Statement) {
+	stmt.Error = stmt.DB.callAfterHooks(stmt.Context, stmt.schema, hookAfterFind, stmt.elements, stmt.Keys, stmt.Error)
+}
+
+// beforeDeleteCallback 调用 BeforeDelete 钩子
+func beforeDeleteCallback(stmt *Statement) {
+	stmt.Error = stmt.DB.callHooks(stmt.Context, stmt.schema, hookBeforeDelete, stmt.elements)
+}
+
+// deleteCallback 删除记录，在 Transaction 中执行时加入事务
+func deleteCallback(stmt *Statement) {
+	if stmt.tx != nil {
+		stmt.Error = stmt.tx.queueDelete(stmt)
+		return
+	}
+	stmt.Error = stmt.DB.remove(stmt)
+}
+
+// afterDeleteCallback 对删除成功的元素调用 AfterDelete 钩子，在 Transaction 中执行时在提交后调用
+func afterDeleteCallback(stmt *Statement) {
+	if stmt.tx != nil {
+		if stmt.Error == nil {
+			stmt.tx.afterCommit(stmt.schema, hookAfterDelete, stmt.elements)
+		}
+		return
+	}
+	stmt.Error = stmt.DB.callAfterHooks(stmt.Context, stmt.schema, hookAfterDelete, stmt.elements, stmt.Keys, stmt.Error)
+}
@@ -0,0 +1,108 @@
+package grm
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Gadget struct {
+	ID        uint
+	Name      string
+	UpdatedAt time.Time
+}
+
+// 测试在默认回调前后注册回调，并修改 Key 和序列化后的值
+func TestCallbackRegister(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	// 为 Key 加上租户前缀，Set、Get 和 Delete 使用同一个回调
+	tenant := func(stmt *Statement) {
+		for i, key := range stmt.Keys {
+			stmt.Keys[i] = "tenant1:" + key
+		}
+	}
+	assert.NoError(t, db.Callback().Set().After("grm:keys").Register("tenant:keys", tenant))
+	assert.NoError(t, db.Callback().Get().After("grm:keys").Register("tenant:keys", tenant))
+	assert.NoError(t, db.Callback().Delete().After("grm:keys").Register("tenant:keys", tenant))
+
+	var payloads [][]byte
+	assert.NoError(t, db.Callback().Set().After("grm:serialize").Register("test:payloads", func(stmt *Statement) {
+		payloads = stmt.Payloads
+	}))
+
+	gadget := Gadget{ID: 1, Name: "lamp"}
+	assert.NoError(t, db.Set(&gadget))
+	assert.True(t, s.Exists("tenant1:grm:gadgets:1"))
+	assert.False(t, s.Exists("grm:gadgets:1"))
+	assert.Len(t, payloads, 1)
+	assert.True(t, bytes.Contains(payloads[0], []byte("lamp")))
+
+	fetched := Gadget{ID: 1}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, "lamp", fetched.Name)
+
+	assert.NoError(t, db.Delete(&fetched))
+	assert.False(t, s.Exists("tenant1:grm:gadgets:1"))
+
+	// 重复注册和不存在的位置返回错误
+	assert.Error(t, db.Callback().Set().Register("tenant:keys", tenant))
+	assert.Error(t, db.Callback().Set().Before("missing").Register("test:missing", tenant))
+}
+
+// 测试替换和移除默认回调，以及回调设置 Error 后中止操作
+func TestCallbackReplaceRemove(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	// 替换 grm:timestamps 后不再维护 UpdatedAt
+	assert.NoError(t, db.Callback().Set().Replace("grm:timestamps", func(stmt *Statement) {}))
+	gadget := Gadget{ID: 1, Name: "lamp"}
+	assert.NoError(t, db.Set(&gadget))
+	assert.True(t, gadget.UpdatedAt.IsZero())
+
+	assert.NoError(t, db.Callback().Set().Before("grm:save").Register("test:validate", func(stmt *Statement) {
+		for _, model := range stmt.Models {
+			if strings.TrimSpace(model.(*Gadget).Name) == "" {
+				stmt.Error = errors.New("name is required")
+			}
+		}
+	}))
+	assert.EqualError(t, db.Set(&Gadget{ID: 2}), "name is required")
+	assert.False(t, s.Exists("grm:gadgets:2"))
+
+	assert.NoError(t, db.Callback().Set().Remove("test:validate"))
+	assert.NoError(t, db.Set(&Gadget{ID: 2}))
+	assert.True(t, s.Exists("grm:gadgets:2"))
+	assert.Error(t, db.Callback().Set().Remove("test:validate"))
+	assert.Error(t, db.Callback().Set().Replace("test:validate", func(stmt *Statement) {}))
+}
+
+// 测试事务中的写入同样经过回调链
+func TestCallbackTransaction(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	var names []string
+	assert.NoError(t, db.Callback().Set().Before("grm:save").Register("test:observe", func(stmt *Statement) {
+		names = append(names, stmt.Models[0].(*Gadget).Name)
+	}))
+
+	err := db.Transaction(db.getContext(), func(tx *Tx) error {
+		return tx.Set(&Gadget{ID: 1, Name: "desk"})
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"desk"}, names)
+	assert.True(t, s.Exists("grm:gadgets:1"))
+}
@@ -0,0 +1,125 @@
+package grm
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Count 返回模型的记录数。模型带索引或唯一字段时读取维护的主键集合（SCARD），否则通过 SCAN 遍历 Key 计数，
+// 此时带 DeletedAt 的模型需要读取每条记录以排除已软删除的记录。
+// 已软删除的记录不在主键集合中，因此 Unscoped 会话对带 DeletedAt 的模型总是通过 SCAN 计数。
+// 主键集合不会随 Key 过期自动清理，带 TTL 的记录过期后仍会被计入
+//
+//	n, err := db.Count(&User{})
+func (db *DB) Count(model interface{}) (int64, error) {
+	s, err := parseSchema(reflect.Indirect(reflect.ValueOf(model)).Type())
+	if err != nil {
+		return 0, err
+	}
+
+	ctx := db.getContext()
+	if s.hasIDSet() && (s.DeletedAt == nil || !db.unscoped) {
+		return db.client.SCard(ctx, db.idSetKey(s)).Result()
+	}
+
+	members, err := db.scanMembers(ctx, s)
+	if err != nil || s.DeletedAt == nil || db.unscoped {
+		return int64(len(members)), err
+	}
+
+	elements := newElements(s, len(members))
+	for i, member := range members {
+		if err := db.setPrimaryKey(s, elements[i], member); err != nil {
+			return 0, err
+		}
+	}
+	keys, err := db.getKeys(elements)
+	if err != nil {
+		return 0, err
+	}
+	failed, err := db.load(ctx, db.client, s, elements, keys)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	for i, key := range keys {
+		if failed[key] == nil && !isDeleted(s, elements[i]) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Exists 判断模型对应的 Key 是否存在，不会读取和解码记录（带 DeletedAt 的模型需要读取记录以排除软删除的记录）
+func (db *DB) Exists(model interface{}) (bool, error) {
+	exists, err := db.ExistsMany(model)
+	if err != nil || len(exists) == 0 {
+		return false, err
+	}
+	return exists[0], nil
+}
+
+// ExistsMany 逐个判断批量模型对应的 Key 是否存在，结果与输入顺序一致
+//
+//	exists, err := db.ExistsMany(&users) // []bool{true, false, ...}
+func (db *DB) ExistsMany(input interface{}) ([]bool, error) {
+	elements, err := processBatch(input)
+	if err != nil || len(elements) == 0 {
+		return nil, err
+	}
+
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := db.getKeys(elements)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := db.getContext()
+	if s.DeletedAt != nil && !db.unscoped {
+		// 需要读取记录才能排除已软删除的记录
+		stored := newElements(s, len(elements))
+		for i, elem := range elements {
+			stored[i].Set(elem)
+		}
+		failed, err := db.load(ctx, db.client, s, stored, keys)
+		if err != nil {
+			return nil, err
+		}
+		exists := make([]bool, len(keys))
+		for i, key := range keys {
+			exists[i] = failed[key] == nil && !isDeleted(s, stored[i])
+		}
+		return exists, nil
+	}
+
+	cmds := make([]*redis.IntCmd, 0, len(keys))
+	_, err = db.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.Exists(ctx, key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make([]bool, len(cmds))
+	for i, cmd := range cmds {
+		exists[i] = cmd.Val() == 1
+	}
+	return exists, nil
+}
+
+// allMembers 返回模型所有记录的主键，带索引或唯一字段时读取主键集合，否则通过 SCAN 遍历
+func (db *DB) allMembers(ctx context.Context, s *schema) ([]string, error) {
+	if s.hasIDSet() {
+		return db.client.SMembers(ctx, db.idSetKey(s)).Result()
+	}
+	return db.scanMembers(ctx, s)
+}
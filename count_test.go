@@ -0,0 +1,131 @@
+package grm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试不带索引的模型通过 SCAN 计数
+func TestCountByScan(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	n, err := db.Count(&TestUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	users := []TestUser{{ID: 1}, {ID: 2}, {ID: 3}}
+	assert.NoError(t, db.Set(&users))
+	n, err = db.Count(&TestUser{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+}
+
+// 测试带索引的模型读取主键集合计数
+func TestCountByIDSet(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	customers := []Customer{{ID: 1, Email: "a"}, {ID: 2, Email: "b"}}
+	assert.NoError(t, db.Set(&customers))
+	members, _ := s.Members("grm:ids:customers")
+	assert.Equal(t, []string{"1", "2"}, members)
+
+	n, err := db.Count(Customer{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	assert.NoError(t, db.Delete(&Customer{ID: 1}))
+	n, _ = db.Count(&Customer{})
+	assert.Equal(t, int64(1), n)
+}
+
+// 测试判断记录是否存在
+func TestExists(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	assert.NoError(t, db.Set(&TestUser{ID: 1}))
+	// 无法解码的值同样视为存在
+	s.Set("grm:test_users:3", "not json")
+
+	exists, err := db.Exists(&TestUser{ID: 1})
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = db.Exists(&TestUser{ID: 2})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	many, err := db.ExistsMany(&[]TestUser{{ID: 1}, {ID: 2}, {ID: 3}})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true}, many)
+
+	_, err = db.Exists(TestUser{ID: 1})
+	assert.Error(t, err)
+}
+
+type Article struct {
+	Model
+	Title string
+}
+
+// 测试嵌入 grm.Model 的模型：不维护主键集合，升级前写入的记录仍然可以计数和遍历，已软删除的记录不计入
+func TestCountSoftDeleteWithoutIDSet(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	// 升级前写入的记录
+	s.Set("grm:articles:a", `{"ID":"a","Title":"old"}`)
+	assert.NoError(t, db.Set(&Article{Model: Model{ID: "b"}, Title: "new"}))
+	assert.False(t, s.Exists("grm:ids:articles"))
+
+	n, err := db.Count(&Article{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	var articles []Article
+	assert.NoError(t, db.Model(&Article{}).AllowScan().Find(&articles))
+	assert.Len(t, articles, 2)
+
+	assert.NoError(t, db.Delete(&Article{Model: Model{ID: "a"}}))
+	n, err = db.Count(&Article{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = db.Unscoped().Count(&Article{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+}
+
+// 测试带主键集合的模型：已软删除的记录不在集合中，Unscoped 会话通过 SCAN 计入它们
+func TestCountSoftDeleteWithIDSet(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	posts := []Post{{ID: 1, Title: "hello", Slug: "hello"}, {ID: 2, Title: "hello", Slug: "hello-2"}}
+	assert.NoError(t, db.Set(&posts))
+	assert.NoError(t, db.Delete(&Post{ID: 1}))
+
+	n, err := db.Count(&Post{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	n, err = db.Unscoped().Count(&Post{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	// 真正删除后两者一致
+	assert.NoError(t, db.Unscoped().Delete(&Post{ID: 1}))
+	n, err = db.Unscoped().Count(&Post{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
@@ -0,0 +1,130 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrAlreadyExists 表示 Create 的 Key 已存在
+var ErrAlreadyExists = errors.New("key already exists")
+
+// writeMode 决定写入时如何处理已存在或不存在的 Key
+type writeMode int
+
+const (
+	writeUpsert writeMode = iota // Set：不存在时创建，存在时覆盖
+	writeCreate                  // Create：只在不存在时写入
+	writeUpdate                  // Update：只在存在时写入
+)
+
+// withWriteMode 设置写入模式，由 Create 和 Update 使用
+func withWriteMode(mode writeMode) SetOption {
+	return func(cfg *setConfig) {
+		cfg.mode = mode
+	}
+}
+
+// writeHashScript 按条件写入整个 Hash
+// ARGV: [NX 或 XX, ttl(毫秒，-1 表示保留原有的过期时间), field1, value1, ...]
+var writeHashScript = redis.NewScript(`
+local exists = redis.call('EXISTS', KEYS[1]) == 1
+if (ARGV[1] == 'NX') == exists then
+	return 0
+end
+local ttl = tonumber(ARGV[2])
+if ttl < 0 then
+	ttl = redis.call('PTTL', KEYS[1])
+end
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], unpack(ARGV, 3))
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// Create 写入不存在的记录，Key 已存在的元素不会被覆盖，返回 ErrAlreadyExists。
+// 批量写入时每个元素独立判断，失败的元素通过 PartialError 返回
+//
+//	if err := db.Create(&user); errors.Is(err, grm.ErrAlreadyExists) { ... }
+func (db *DB) Create(input interface{}, opts ...SetOption) error {
+	if db.partial() {
+		return errors.New("Select is not supported by Create")
+	}
+	return db.Set(input, append(opts, withWriteMode(writeCreate))...)
+}
+
+// Update 覆盖已存在的记录，Key 不存在的元素不会被创建，返回 ErrNotFound。
+// 批量写入时每个元素独立判断，失败的元素通过 PartialError 返回
+func (db *DB) Update(input interface{}, opts ...SetOption) error {
+	return db.Set(input, append(opts, withWriteMode(writeUpdate))...)
+}
+
+// modeError 返回写入模式下 Key 存在与否对应的错误，exists 为 Key 当前是否存在
+func modeError(mode writeMode, exists bool) error {
+	switch {
+	case mode == writeCreate && exists:
+		return ErrAlreadyExists
+	case mode == writeUpdate && !exists:
+		return ErrNotFound
+	}
+	return nil
+}
+
+// setConditional 使用 SET NX/XX（Hash 模式使用 Lua 脚本）逐个写入，每个元素的结果独立。
+// String 模式下写入 payloads，Hash 模式下 payloads 为 nil
+func (db *DB) setConditional(ctx context.Context, s *schema, elements []reflect.Value, keys []string, payloads [][]byte, cfg *setConfig) error {
+	cond := "NX"
+	if cfg.mode == writeUpdate {
+		cond = "XX"
+	}
+
+	pipe := db.client.Pipeline()
+	cmds := make([]redis.Cmder, 0, len(elements))
+	for i, elem := range elements {
+		if db.storageMode(s) == StorageHash {
+			values, err := db.encodeHash(s, elem)
+			if err != nil {
+				return err
+			}
+			args := append([]interface{}{cond, db.hashTTL(cfg)}, values...)
+			cmds = append(cmds, writeHashScript.Eval(ctx, pipe, []string{keys[i]}, args...))
+			continue
+		}
+
+		cmds = append(cmds, pipe.SetArgs(ctx, keys[i], payloads[i], db.setArgs(cfg, cond)))
+	}
+	// 条件不满足的 SET 返回 redis.Nil，各元素的结果在下面逐个检查
+	pipe.Exec(ctx)
+
+	failed := make(map[string]error)
+	for i, cmd := range cmds {
+		written := true
+		switch cmd := cmd.(type) {
+		case *redis.Cmd:
+			n, err := cmd.Int()
+			if err != nil {
+				failed[keys[i]] = err
+				continue
+			}
+			written = n == 1
+		default:
+			if err := cmd.Err(); errors.Is(err, redis.Nil) {
+				written = false
+			} else if err != nil {
+				failed[keys[i]] = err
+				continue
+			}
+		}
+		if !written {
+			failed[keys[i]] = modeError(cfg.mode, cfg.mode == writeCreate)
+		}
+	}
+	if len(failed) > 0 {
+		return &PartialError{Errors: failed}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package grm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试 Create 只写入不存在的记录
+func TestCreate(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		assert.NoError(t, db.Create(&TestUser{ID: 1, Name: "Alice"}, WithTTL(time.Minute)))
+		assert.Equal(t, time.Minute, s.TTL("grm:test_users:1"))
+
+		users := []TestUser{{ID: 1, Name: "Mallory"}, {ID: 2, Name: "Bob"}}
+		err := db.Create(&users)
+		var partial *PartialError
+		assert.True(t, errors.As(err, &partial))
+		assert.Len(t, partial.Errors, 1)
+		assert.ErrorIs(t, partial.Errors["grm:test_users:1"], ErrAlreadyExists)
+
+		fetched := []TestUser{{ID: 1}, {ID: 2}}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "Alice", fetched[0].Name)
+		assert.Equal(t, "Bob", fetched[1].Name)
+		s.Close()
+	}
+}
+
+// 测试 Update 只覆盖已存在的记录
+func TestUpdate(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		assert.NoError(t, db.Set(&TestUser{ID: 1, Name: "Alice"}))
+
+		users := []TestUser{{ID: 1, Name: "Alicia"}, {ID: 2, Name: "Bob"}}
+		err := db.Update(&users)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.False(t, s.Exists("grm:test_users:2"))
+
+		fetched := TestUser{ID: 1}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "Alicia", fetched.Name)
+		s.Close()
+	}
+}
+
+// 测试需要 WATCH 的模型同样支持 Create 和 Update
+func TestCreateUpdateWatched(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	assert.NoError(t, db.Create(&Customer{ID: 1, Email: "a@b.c"}))
+	assert.ErrorIs(t, db.Create(&Customer{ID: 1, Email: "d@e.f"}), ErrAlreadyExists)
+	members, _ := s.Members("grm:idx:customers:email:a@b.c")
+	assert.Equal(t, []string{"1"}, members)
+	assert.False(t, s.Exists("grm:idx:customers:email:d@e.f"))
+
+	assert.ErrorIs(t, db.Update(&Customer{ID: 2, Email: "g@h.i"}), ErrNotFound)
+	assert.False(t, s.Exists("grm:customers:2"))
+	assert.NoError(t, db.Update(&Customer{ID: 1, Email: "d@e.f"}))
+	assert.False(t, s.Exists("grm:idx:customers:email:a@b.c"))
+}
+
+// 测试 WithAtomic 时 Create、Update 和部分更新全部写入或全部不写入
+func TestConditionalAtomic(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		assert.NoError(t, db.Set(&TestUser{ID: 2, Name: "Bob"}))
+
+		users := []TestUser{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Mallory"}}
+		err := db.Create(&users, WithTTL(time.Minute), WithAtomic())
+		var partial *PartialError
+		assert.True(t, errors.As(err, &partial))
+		assert.ErrorIs(t, partial.Errors["grm:test_users:1"], ErrBatchAborted)
+		assert.ErrorIs(t, partial.Errors["grm:test_users:2"], ErrAlreadyExists)
+		assert.False(t, s.Exists("grm:test_users:1"))
+
+		users = []TestUser{{ID: 2, Name: "Bobby"}, {ID: 3, Name: "Carol"}}
+		err = db.Update(&users, WithAtomic())
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, ErrBatchAborted)
+		err = db.Select("Name").Set(&users, WithAtomic())
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, ErrBatchAborted)
+
+		fetched := TestUser{ID: 2}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "Bob", fetched.Name)
+
+		// 全部成功时正常写入
+		assert.NoError(t, db.Create(&[]TestUser{{ID: 4}, {ID: 5}}, WithAtomic()))
+		assert.True(t, s.Exists("grm:test_users:5"))
+		s.Close()
+	}
+}
@@ -1,6 +1,15 @@
 package grm
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound 表示 Key 在 Redis 中不存在
+var ErrNotFound = errors.New("key not found")
+
+// ErrBatchAborted 表示使用 WithAtomic 时，因批次中的其他元素失败，该元素没有被写入
+var ErrBatchAborted = errors.New("batch aborted")
 
 // 定义复合错误类型，包含具体错误信息
 type PartialError struct {
@@ -10,3 +19,12 @@ type PartialError struct {
 func (e *PartialError) Error() string {
 	return fmt.Sprintf("partial error (%d failures)", len(e.Errors))
 }
+
+// Unwrap 返回各个 Key 的错误，使 errors.Is(err, ErrUniqueViolation) 等判断可以穿透 PartialError
+func (e *PartialError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
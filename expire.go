@@ -0,0 +1,120 @@
+package grm
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NoExpiration 是 TTL 对没有过期时间的记录返回的值
+const NoExpiration time.Duration = -1
+
+// Expire 设置记录的过期时间，d 不大于 0 时记录会被立即删除。
+// Key 不存在的元素返回 ErrNotFound，批量操作时通过 PartialError 返回
+//
+//	db.Expire(&user, 10*time.Minute)
+func (db *DB) Expire(input interface{}, d time.Duration) error {
+	return db.expire(input, func(ctx context.Context, pipe redis.Pipeliner, key string) func() bool {
+		return pipe.PExpire(ctx, key, d).Val
+	})
+}
+
+// ExpireAt 设置记录在 t 时刻过期，t 早于当前时间时记录会被立即删除。
+// Key 不存在的元素返回 ErrNotFound，批量操作时通过 PartialError 返回
+func (db *DB) ExpireAt(input interface{}, t time.Time) error {
+	return db.expire(input, func(ctx context.Context, pipe redis.Pipeliner, key string) func() bool {
+		return pipe.PExpireAt(ctx, key, t).Val
+	})
+}
+
+// Persist 移除记录的过期时间，使记录永久保存。Key 不存在的元素返回 ErrNotFound，批量操作时通过 PartialError 返回
+func (db *DB) Persist(input interface{}) error {
+	return db.expire(input, func(ctx context.Context, pipe redis.Pipeliner, key string) func() bool {
+		// 没有过期时间的记录 PERSIST 同样返回 0，需要通过 EXISTS 判断记录是否存在
+		exists := pipe.Exists(ctx, key)
+		pipe.Persist(ctx, key)
+		return func() bool { return exists.Val() == 1 }
+	})
+}
+
+// TTL 返回每个元素剩余的过期时间，结果与输入顺序一致，没有过期时间的记录为 NoExpiration。
+// Key 不存在的元素结果为 0，并通过 PartialError 返回 ErrNotFound
+//
+//	ttls, err := db.TTL(&users)
+func (db *DB) TTL(input interface{}) ([]time.Duration, error) {
+	keys, err := db.keysOf(input)
+	if err != nil || len(keys) == 0 {
+		return nil, err
+	}
+
+	ctx := db.getContext()
+	cmds := make([]*redis.DurationCmd, 0, len(keys))
+	_, err = db.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.PTTL(ctx, key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ttls := make([]time.Duration, len(cmds))
+	failed := make(map[string]error)
+	for i, cmd := range cmds {
+		// PTTL 对不存在的 Key 返回 -2，对没有过期时间的 Key 返回 -1
+		switch ttl := cmd.Val(); ttl {
+		case -2:
+			failed[keys[i]] = ErrNotFound
+		case -1:
+			ttls[i] = NoExpiration
+		default:
+			ttls[i] = ttl
+		}
+	}
+	if len(failed) > 0 {
+		return ttls, &PartialError{Errors: failed}
+	}
+	return ttls, nil
+}
+
+// expire 在一个 Pipeline 中对每个元素的 Key 加入命令，fn 返回的函数在执行后判断 Key 是否存在
+func (db *DB) expire(input interface{}, fn func(ctx context.Context, pipe redis.Pipeliner, key string) func() bool) error {
+	keys, err := db.keysOf(input)
+	if err != nil || len(keys) == 0 {
+		return err
+	}
+
+	ctx := db.getContext()
+	results := make([]func() bool, 0, len(keys))
+	_, err = db.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			results = append(results, fn(ctx, pipe, key))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := make(map[string]error)
+	for i, exists := range results {
+		if !exists() {
+			failed[keys[i]] = ErrNotFound
+		}
+	}
+	if len(failed) > 0 {
+		return &PartialError{Errors: failed}
+	}
+	return nil
+}
+
+// keysOf 返回批量模型中每个元素的 Key
+func (db *DB) keysOf(input interface{}) ([]string, error) {
+	elements, err := processBatch(input)
+	if err != nil || len(elements) == 0 {
+		return nil, err
+	}
+	return db.getKeys(elements)
+}
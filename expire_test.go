@@ -0,0 +1,75 @@
+package grm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Lease struct {
+	ID     uint
+	Holder string
+}
+
+// 测试 Expire、ExpireAt、Persist 和 TTL
+func TestExpire(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	leases := []Lease{{ID: 1, Holder: "a"}, {ID: 2, Holder: "b"}}
+	assert.NoError(t, db.Set(&leases))
+
+	assert.NoError(t, db.Expire(&leases[0], time.Minute))
+	assert.NoError(t, db.ExpireAt(&leases[1], time.Now().Add(time.Hour)))
+
+	ttls, err := db.TTL(&leases)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, ttls[0])
+	assert.InDelta(t, float64(time.Hour), float64(ttls[1]), float64(time.Second))
+
+	assert.NoError(t, db.Persist(&leases[0]))
+	// 没有过期时间的记录 Persist 同样成功
+	assert.NoError(t, db.Persist(&leases[0]))
+
+	// 不存在的记录返回 ErrNotFound
+	batch := []Lease{{ID: 1}, {ID: 3}}
+	ttls, err = db.TTL(&batch)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, []time.Duration{NoExpiration, 0}, ttls)
+	assert.ErrorIs(t, db.Expire(&batch, time.Minute), ErrNotFound)
+	assert.ErrorIs(t, db.Persist(&Lease{ID: 3}), ErrNotFound)
+	assert.Equal(t, time.Minute, s.TTL("grm:leases:1"))
+}
+
+// 测试 WithKeepTTL 保留记录原有的过期时间
+func TestKeepTTL(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	lease := Lease{ID: 1, Holder: "a"}
+	assert.NoError(t, db.Set(&lease, WithTTL(time.Minute)))
+
+	lease.Holder = "b"
+	assert.NoError(t, db.Set(&lease, WithKeepTTL()))
+	assert.Equal(t, time.Minute, s.TTL("grm:leases:1"))
+	assert.NoError(t, db.Update(&lease, WithKeepTTL()))
+	assert.Equal(t, time.Minute, s.TTL("grm:leases:1"))
+	// 普通 Set 会移除过期时间
+	assert.NoError(t, db.Set(&lease))
+	assert.Equal(t, time.Duration(0), s.TTL("grm:leases:1"))
+
+	// Hash 模式
+	session := Session{ID: "s1", Token: "a"}
+	assert.NoError(t, db.Set(&session, WithTTL(time.Minute)))
+	session.Token = "b"
+	assert.NoError(t, db.Set(&session, WithKeepTTL()))
+	assert.Equal(t, time.Minute, s.TTL("grm:sessions:s1"))
+	assert.Equal(t, "b", s.HGet("grm:sessions:s1", "token"))
+	assert.NoError(t, db.Update(&session, WithKeepTTL()))
+	assert.Equal(t, time.Minute, s.TTL("grm:sessions:s1"))
+}
@@ -7,17 +7,31 @@ import (
 	"reflect"
 	"time"
 
-	"github.com/kenshaw/snaker"
 	"github.com/redis/go-redis/v9"
 )
 
 type DB struct {
-	client     *redis.Client
-	serializer Serializer
+	client         *redis.Client
+	serializer     Serializer
+	namingStrategy NamingStrategy
+	storage        StorageMode
+	callbacks      *Callbacks
+	idGenerator    IDGenerator
+	softDeleteTTL  time.Duration
+	ttlJitter      float64 // 过期时间的随机抖动百分比，通过 WithTTLJitter 设置
+
+	ctx      context.Context // 会话级别的 context，通过 WithContext 设置
+	selects  []string        // 只写入的字段，通过 Select 设置
+	unscoped bool            // 包含软删除的记录，通过 Unscoped 设置
 }
 
 // Open 连接 Redis，返回 GRM 的 DB 实例
 func Open(config *Options, opts ...DBOption) (*DB, error) {
+	return OpenContext(context.Background(), config, opts...)
+}
+
+// OpenContext 与 Open 相同，但建立连接和 Ping 时使用 ctx，可用于控制连接超时
+func OpenContext(ctx context.Context, config *Options, opts ...DBOption) (*DB, error) {
 	// 如果精简字段有值，覆盖 RedisOptions 的对应字段
 	if config.Addr != "" {
 		config.RedisOptions.Addr = config.Addr
@@ -30,13 +44,16 @@ func Open(config *Options, opts ...DBOption) (*DB, error) {
 	}
 
 	client := redis.NewClient(&config.RedisOptions)
-	if err := client.Ping(context.Background()).Err(); err != nil {
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
 		return nil, err
 	}
 
 	db := &DB{
-		client:     client,
-		serializer: JSONSerializer,
+		client:         client,
+		serializer:     JSONSerializer,
+		namingStrategy: DefaultNamingStrategy,
+		callbacks:      newCallbacks(),
 	}
 
 	for _, opt := range opts {
@@ -45,6 +62,25 @@ func Open(config *Options, opts ...DBOption) (*DB, error) {
 	return db, nil
 }
 
+// WithContext 返回绑定 ctx 的新会话，会话中的所有 Redis 调用都使用该 ctx
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), time.Second)
+//	defer cancel()
+//	db.WithContext(ctx).Get(&user)
+func (db *DB) WithContext(ctx context.Context) *DB {
+	tx := *db
+	tx.ctx = ctx
+	return &tx
+}
+
+// getContext 返回当前会话的 ctx，未设置时为 context.Background()
+func (db *DB) getContext() context.Context {
+	if db.ctx == nil {
+		return context.Background()
+	}
+	return db.ctx
+}
+
 func (db *DB) Set(input interface{}, opts ...SetOption) error {
 	cfg := &setConfig{}
 	for _, opt := range opts {
@@ -52,92 +88,159 @@ func (db *DB) Set(input interface{}, opts ...SetOption) error {
 	}
 
 	elements, err := processBatch(input)
-	if err != nil {
+	if err != nil || len(elements) == 0 {
 		return err
 	}
 
-	ctx := context.Background()
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return err
+	}
+	// 在回调加入 UpdatedAt 之前检查 Select 的字段
+	if db.partial() {
+		if _, err := db.selectedFields(s); err != nil {
+			return err
+		}
+	}
 
-	// 如果有 TTL，使用 Pipeline 逐个设置（因为 MSet 不支持 TTL）
-	if cfg.ttl > 0 {
-		pipe := db.client.Pipeline()
+	stmt := db.statement(db.getContext(), s, elements)
+	stmt.TTL, stmt.cfg = db.ttlFor(s, cfg), cfg
+	return db.callbacks.set.execute(stmt)
+}
 
-		for _, elem := range elements {
-			model := elem.Addr().Interface()
-			updateTimestamps(elem)
+// save 按写入模式和存储模式写入记录，是默认的 grm:save 回调
+func (db *DB) save(stmt *Statement) error {
+	ctx, s, elements, keys, cfg := stmt.Context, stmt.schema, stmt.elements, stmt.Keys, stmt.cfg
+	cfg.ttl = stmt.TTL
 
-			key, err := getKey(model)
-			if err != nil {
-				return err
-			}
+	if db.partial() {
+		return db.setPartial(ctx, s, elements, keys, cfg)
+	}
+	if watched(s, cfg) {
+		return db.saveWatched(ctx, s, elements, keys, nil, cfg)
+	}
+	if db.storageMode(s) == StorageHash {
+		if cfg.mode != writeUpsert {
+			return db.setConditional(ctx, s, elements, keys, nil, cfg)
+		}
+		return db.setHash(ctx, s, elements, keys, cfg)
+	}
 
-			data, err := db.serializer.Marshal(model)
-			if err != nil {
-				return err
-			}
+	payloads := stmt.Payloads
+	if len(payloads) != len(elements) {
+		return fmt.Errorf("grm:save expects %d payloads, got %d", len(elements), len(payloads))
+	}
+	if cfg.mode != writeUpsert {
+		return db.setConditional(ctx, s, elements, keys, payloads, cfg)
+	}
 
+	// 如果有 TTL 或需要保留原有的 TTL，使用 Pipeline 逐个设置（因为 MSet 不支持 TTL）
+	if cfg.ttl > 0 || cfg.keepTTL {
+		// WithAtomic 时在 MULTI/EXEC 中写入，保证整个批次全部写入或全部不写入
+		pipe := db.client.Pipeline()
+		if cfg.atomic {
+			pipe = db.client.TxPipeline()
+		}
+		cmds := make([]*redis.StatusCmd, 0, len(keys))
+		for i, key := range keys {
 			// 设置带 TTL 的键值
-			pipe.Set(ctx, key, data, cfg.ttl)
+			cmds = append(cmds, pipe.SetArgs(ctx, key, payloads[i], db.setArgs(cfg, "")))
 		}
 
-		_, err = pipe.Exec(ctx)
+		_, err := pipe.Exec(ctx)
+		if err == nil || cfg.atomic {
+			return err
+		}
+		// 非原子写入时各元素的结果独立，失败的元素通过 PartialError 返回
+		failed := make(map[string]error)
+		for i, cmd := range cmds {
+			if err := cmd.Err(); err != nil {
+				failed[keys[i]] = err
+			}
+		}
+		if len(failed) > 0 {
+			return &PartialError{Errors: failed}
+		}
 		return err
 	}
 
 	// 收集键值对（格式: [key1, value1, key2, value2, ...]）
 	keyValues := make([]interface{}, 0, len(elements)*2)
-	// 无 TTL，使用 MSet 批量写入（性能更优）
-	for _, elem := range elements {
-		if elem.Kind() != reflect.Struct {
-			return errors.New("element must be a struct")
-		}
+	// 无 TTL，使用 MSet 批量写入（性能更优），MSet 本身是原子的
+	for i, key := range keys {
+		keyValues = append(keyValues, key, payloads[i])
+	}
 
-		model := elem.Addr().Interface()
-		updateTimestamps(elem)
+	return db.client.MSet(ctx, keyValues...).Err()
+}
 
-		key, err := getKey(model)
-		if err != nil {
-			return err
-		}
+func (db *DB) Get(input interface{}, opts ...GetOption) error {
+	cfg := &getConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
 
-		data, err := db.serializer.Marshal(model)
-		if err != nil {
-			return err
-		}
+	elements, err := processBatch(input)
+	if err != nil || len(elements) == 0 {
+		return err
+	}
 
-		keyValues = append(keyValues, key, data)
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return err
 	}
 
-	return db.client.MSet(ctx, keyValues...).Err()
+	stmt := db.statement(db.getContext(), s, elements)
+	stmt.TTL = touchFor(s, cfg)
+	return db.callbacks.get.execute(stmt)
 }
 
-func (db *DB) Get(input interface{}) error {
-	elements, err := processBatch(input)
+// query 读取记录，已软删除的记录视为不存在，是默认的 grm:query 回调。
+// stmt.TTL 大于 0 时刷新读取成功的记录的过期时间，在事务中执行时刷新在提交时进行
+func (db *DB) query(stmt *Statement) error {
+	var c redis.Cmdable = db.client
+	if tx := stmt.tx; tx != nil {
+		c = tx.rtx
+		if tx.cfg.watchReads {
+			if err := tx.watch(stmt.Keys); err != nil {
+				return err
+			}
+		}
+	}
+
+	errors, err := db.load(stmt.Context, c, stmt.schema, stmt.elements, stmt.Keys)
 	if err != nil {
 		return err
 	}
-
-	keys := make([]string, 0, len(elements))
-	for _, elem := range elements {
-		model := elem.Addr().Interface()
-		key, err := getKey(model)
-		if err != nil {
+	db.hideDeleted(stmt.schema, stmt.elements, stmt.Keys, errors)
+	if stmt.TTL > 0 {
+		if err := db.touch(stmt, errors); err != nil {
 			return err
 		}
-		keys = append(keys, key)
+	}
+	if len(errors) > 0 {
+		return &PartialError{Errors: errors}
+	}
+	return nil
+}
+
+// load 读取 keys 对应的记录到 elements，返回读取失败的 Key 及原因
+// c 可以是 DB 的客户端，也可以是 WATCH 事务中的 *redis.Tx
+func (db *DB) load(ctx context.Context, c redis.Cmdable, s *schema, elements []reflect.Value, keys []string) (map[string]error, error) {
+	if db.storageMode(s) == StorageHash {
+		return db.loadHash(ctx, c, s, elements, keys)
 	}
 
-	ctx := context.Background()
-	values, err := db.client.MGet(ctx, keys...).Result()
+	values, err := c.MGet(ctx, keys...).Result()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	errors := make(map[string]error)
 	for i, val := range values {
 		key := keys[i]
 		if val == nil {
-			errors[key] = fmt.Errorf("key not found")
+			errors[key] = ErrNotFound
 			continue
 		}
 
@@ -146,52 +249,34 @@ func (db *DB) Get(input interface{}) error {
 			errors[key] = fmt.Errorf("decode error: %v", err)
 		}
 	}
-
-	if len(errors) > 0 {
-		return &PartialError{Errors: errors}
-	}
-	return nil
+	return errors, nil
 }
 
 func (db *DB) Delete(input interface{}) error {
 	elements, err := processBatch(input)
-	if err != nil {
+	if err != nil || len(elements) == 0 {
 		return err
 	}
 
-	ctx := context.Background()
-	keys := make([]string, 0, len(elements))
-
-	for _, elem := range elements {
-		model := elem.Addr().Interface()
-		key, err := getKey(model)
-		if err != nil {
-			return err
-		}
-		keys = append(keys, key)
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return err
 	}
 
-	return db.client.Del(ctx, keys...).Err()
+	return db.callbacks.delete.execute(db.statement(db.getContext(), s, elements))
 }
 
-// getKey 生成 Redis Key，格式为 "struct_prefix:id"
-func getKey(model interface{}) (string, error) {
-	// 获取结构体类型名称（如 "User"）
-	t := reflect.TypeOf(model).Elem()
-	structName := t.Name()
-
-	// 转换为 snake_case 并复数化（如 "users"）
-	prefix := snaker.CamelToSnake(structName) + "s"
-
-	// 提取 ID 字段的值
-	v := reflect.ValueOf(model).Elem()
-	idField := v.FieldByName("ID")
-	if !idField.IsValid() {
-		return "", fmt.Errorf("model must have an 'ID' field")
+// remove 删除记录，带 DeletedAt 的模型为软删除，是默认的 grm:delete 回调
+func (db *DB) remove(stmt *Statement) error {
+	ctx, s, elements, keys := stmt.Context, stmt.schema, stmt.elements, stmt.Keys
+	switch {
+	case s.DeletedAt != nil && !db.unscoped:
+		return db.softDelete(ctx, s, elements, keys)
+	case s.needsWatch():
+		return db.deleteWatched(ctx, s, elements, keys)
+	default:
+		return db.client.Del(ctx, keys...).Err()
 	}
-	id := fmt.Sprintf("%v", idField.Interface())
-
-	return fmt.Sprintf("grm:%s:%s", prefix, id), nil
 }
 
 func updateTimestamps(v reflect.Value) {
@@ -236,3 +321,32 @@ func processBatch(input interface{}) ([]reflect.Value, error) {
 		return nil, errors.New("input must be a pointer to struct or slice/array")
 	}
 }
+
+// destSchema 解析查询结果的目标，dest 必须是结构体切片或结构体指针切片的指针
+func destSchema(dest interface{}) (*schema, error) {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("dest must be a pointer to a slice")
+	}
+
+	elemType := t.Elem().Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	return parseSchema(elemType)
+}
+
+// setSlice 将查询到的元素写入 dest 指向的切片，支持 []T 和 []*T
+func setSlice(dest interface{}, elements []reflect.Value) {
+	slice := reflect.ValueOf(dest).Elem()
+	result := reflect.MakeSlice(slice.Type(), 0, len(elements))
+	byPointer := slice.Type().Elem().Kind() == reflect.Ptr
+	for _, elem := range elements {
+		if byPointer {
+			result = reflect.Append(result, elem.Addr())
+		} else {
+			result = reflect.Append(result, elem)
+		}
+	}
+	slice.Set(result)
+}
@@ -1,11 +1,14 @@
 package grm
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -38,6 +41,45 @@ func TestOpenConnection(t *testing.T) {
 	assert.NotNil(t, db.client)
 }
 
+// 测试 OpenContext 遵守 ctx 的取消
+func TestOpenContext(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := OpenContext(ctx, &Options{Addr: s.Addr()})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	db, err := OpenContext(context.Background(), &Options{Addr: s.Addr()})
+	assert.NoError(t, err)
+	assert.NotNil(t, db.client)
+}
+
+// 测试 WithContext 会话将 ctx 传递给 Redis 调用
+func TestWithContext(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := db.WithContext(ctx)
+
+	user := TestUser{ID: 1, Name: "Alice"}
+	assert.NoError(t, session.Set(&user))
+	assert.NoError(t, session.Get(&user))
+
+	cancel()
+	assert.ErrorIs(t, session.Set(&user), context.Canceled)
+	assert.ErrorIs(t, session.Get(&user), context.Canceled)
+	assert.ErrorIs(t, session.Delete(&user), context.Canceled)
+
+	// 原 DB 不受会话影响
+	assert.NoError(t, db.Get(&user))
+}
+
 // 测试 Set 和 Get 操作
 func TestSetAndGet(t *testing.T) {
 	s := setupTestRedis()
@@ -108,7 +150,7 @@ func TestDelete(t *testing.T) {
 	// 验证用户不存在
 	err = db.Get(&user)
 	assert.Error(t, err) // 应返回 redis.Nil 错误
-	key, _ := getKey(&user)
+	key, _ := db.getKey(&user)
 	assert.Equal(t, &PartialError{Errors: map[string]error{key: fmt.Errorf("key not found")}}, err)
 }
 
@@ -149,8 +191,9 @@ func TestKeyGeneration(t *testing.T) {
 		ID uint
 	}
 	p := Product{ID: 42}
+	db := &DB{namingStrategy: DefaultNamingStrategy}
 
-	key, err := getKey(&p)
+	key, err := db.getKey(&p)
 	assert.NoError(t, err)
 	assert.Equal(t, "grm:products:42", key)
 }
@@ -161,8 +204,9 @@ func TestInvalidModel(t *testing.T) {
 		Name string
 	}
 	inv := Invalid{Name: "test"}
+	db := &DB{namingStrategy: DefaultNamingStrategy}
 
-	_, err := getKey(&inv)
+	_, err := db.getKey(&inv)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "must have an 'ID' field")
 }
@@ -212,3 +256,60 @@ func BenchmarkConcurrentSet(b *testing.B) {
 		}
 	})
 }
+
+// failingHook 模拟 Pipeline 中途失败：MULTI/EXEC 整体失败，普通 Pipeline 只执行第一个命令
+type failingHook struct {
+	multi bool // 最近一次 Pipeline 是否为 MULTI/EXEC
+}
+
+func (h *failingHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (h *failingHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook { return next }
+
+func (h *failingHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		h.multi = cmds[0].Name() == "multi"
+		failure := errors.New("connection reset")
+		if h.multi {
+			for _, cmd := range cmds {
+				cmd.SetErr(failure)
+			}
+			return failure
+		}
+		err := next(ctx, cmds[:1])
+		for _, cmd := range cmds[1:] {
+			cmd.SetErr(failure)
+		}
+		if err != nil {
+			return err
+		}
+		return failure
+	}
+}
+
+// 测试带 TTL 的批量写入：WithAtomic 时全部写入或全部不写入，否则返回每个元素的结果
+func TestAtomicBatch(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	hook := &failingHook{}
+	db.client.AddHook(hook)
+
+	leases := []Lease{{ID: 1, Holder: "a"}, {ID: 2, Holder: "b"}}
+	err := db.Set(&leases, WithTTL(time.Minute))
+	assert.False(t, hook.multi)
+	var partial *PartialError
+	assert.ErrorAs(t, err, &partial)
+	assert.Len(t, partial.Errors, 1)
+	assert.Contains(t, partial.Errors, "grm:leases:2")
+	assert.True(t, s.Exists("grm:leases:1"))
+	assert.False(t, s.Exists("grm:leases:2"))
+
+	s.FlushAll()
+	err = db.Set(&leases, WithTTL(time.Minute), WithAtomic())
+	assert.True(t, hook.multi)
+	assert.EqualError(t, err, "connection reset")
+	assert.False(t, s.Exists("grm:leases:1"))
+	assert.False(t, s.Exists("grm:leases:2"))
+}
@@ -0,0 +1,213 @@
+package grm
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StorageMode 决定模型在 Redis 中的存储结构
+type StorageMode int
+
+const (
+	// StorageString 将整个模型序列化为一个字符串（默认）
+	StorageString StorageMode = iota
+	// StorageHash 将模型存为 Hash，每个字段单独编码为一个 Hash field
+	StorageHash
+)
+
+// StorageModer 由模型实现，用于覆盖 DB 级别的存储模式
+//
+//	func (Session) RedisStorageMode() grm.StorageMode { return grm.StorageHash }
+type StorageModer interface {
+	RedisStorageMode() StorageMode
+}
+
+// storageMode 返回模型实际使用的存储模式，模型声明优先于 DB 配置
+func (db *DB) storageMode(s *schema) StorageMode {
+	if s.StorageMode != nil {
+		return *s.StorageMode
+	}
+	return db.storage
+}
+
+// setHash 以 Hash 结构写入模型，整个批次在一个 MULTI/EXEC 中执行
+func (db *DB) setHash(ctx context.Context, s *schema, elements []reflect.Value, keys []string, cfg *setConfig) error {
+	pipe := db.client.TxPipeline()
+
+	for i, elem := range elements {
+		fields, err := db.encodeHash(s, elem)
+		if err != nil {
+			return err
+		}
+
+		queueReplaceHash(ctx, pipe, keys[i], fields, cfg)
+		if cfg.ttl > 0 {
+			pipe.Expire(ctx, keys[i], db.expiration(cfg.ttl))
+		}
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// replaceHashScript 替换整个 Hash 并保留原有的过期时间，用于 WithKeepTTL
+// ARGV: [field1, value1, ...]
+var replaceHashScript = redis.NewScript(`
+local ttl = redis.call('PTTL', KEYS[1])
+redis.call('DEL', KEYS[1])
+redis.call('HSET', KEYS[1], unpack(ARGV))
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// queueReplaceHash 将替换整个 Hash 的命令加入 pipe，先删除旧 Hash，避免残留已被置空的字段
+func queueReplaceHash(ctx context.Context, pipe redis.Pipeliner, key string, values []interface{}, cfg *setConfig) {
+	if cfg.keepTTL {
+		replaceHashScript.Eval(ctx, pipe, []string{key}, values...)
+		return
+	}
+	pipe.Del(ctx, key)
+	pipe.HSet(ctx, key, values...)
+}
+
+// loadHash 使用 HGETALL 读取 Hash 结构的模型
+func (db *DB) loadHash(ctx context.Context, c redis.Cmdable, s *schema, elements []reflect.Value, keys []string) (map[string]error, error) {
+	cmds := make([]*redis.MapStringStringCmd, 0, len(keys))
+	_, err := c.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			cmds = append(cmds, pipe.HGetAll(ctx, key))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	errors := make(map[string]error)
+	for i, cmd := range cmds {
+		key := keys[i]
+		values := cmd.Val()
+		if len(values) == 0 {
+			errors[key] = ErrNotFound
+			continue
+		}
+
+		if err := db.decodeHash(s, elements[i], values); err != nil {
+			errors[key] = fmt.Errorf("decode error: %v", err)
+		}
+	}
+	return errors, nil
+}
+
+// encodeHash 将模型编码为 HSET 参数（格式: [field1, value1, field2, value2, ...]），nil 指针字段不写入
+func (db *DB) encodeHash(s *schema, v reflect.Value) ([]interface{}, error) {
+	values := make([]interface{}, 0, len(s.Fields)*2)
+	for _, f := range s.Fields {
+		fv := v.FieldByIndex(f.Index)
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			continue
+		}
+
+		data, err := db.encodeField(fv)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		values = append(values, f.Column, data)
+	}
+	return values, nil
+}
+
+// decodeHash 将 HGETALL 的结果写回模型，Hash 中不存在的字段会被置为零值
+func (db *DB) decodeHash(s *schema, v reflect.Value, values map[string]string) error {
+	for _, f := range s.Fields {
+		fv := v.FieldByIndex(f.Index)
+		data, ok := values[f.Column]
+		if !ok {
+			if !f.PrimaryKey {
+				fv.Set(reflect.Zero(f.Type))
+			}
+			continue
+		}
+
+		if err := db.decodeField(fv, data); err != nil {
+			return fmt.Errorf("field %s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// encodeField 编码单个字段：实现了 encoding.TextMarshaler 的类型（如 time.Time）使用其文本形式，
+// 数字和布尔值使用十进制文本（可被 HINCRBY 等命令直接操作），其余复合类型使用 DB 配置的 Serializer
+func (db *DB) encodeField(v reflect.Value) (string, error) {
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if m, ok := addressable(v).Addr().Interface().(encoding.TextMarshaler); ok {
+		data, err := m.MarshalText()
+		return string(data), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), nil
+		}
+	}
+
+	data, err := db.serializer.Marshal(addressable(v).Addr().Interface())
+	return string(data), err
+}
+
+// decodeField 是 encodeField 的逆过程
+func (db *DB) decodeField(v reflect.Value, data string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	if _, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return setFieldFromString(v, data)
+	}
+
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Interface:
+		return db.serializer.Unmarshal([]byte(data), v.Addr().Interface())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes([]byte(data))
+			return nil
+		}
+		return db.serializer.Unmarshal([]byte(data), v.Addr().Interface())
+	}
+	return setFieldFromString(v, data)
+}
+
+// addressable 返回可寻址的值，必要时复制一份
+func addressable(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p.Elem()
+}
@@ -0,0 +1,129 @@
+package grm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Address struct {
+	City   string
+	Street string
+}
+
+type Profile struct {
+	ID        uint
+	Name      string `grm:"column=display_name"`
+	Age       int
+	Score     float64
+	Active    bool
+	Nickname  *string
+	Address   Address
+	Tags      []string
+	Secret    string `grm:"-"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type Session struct {
+	ID    string
+	Token string
+}
+
+func (Session) RedisStorageMode() StorageMode { return StorageHash }
+
+// 测试 Hash 模式下各字段的编码
+func TestHashStorage(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(StorageHash))
+
+	profile := Profile{
+		ID:      1,
+		Name:    "Alice",
+		Age:     30,
+		Score:   9.5,
+		Active:  true,
+		Address: Address{City: "Paris", Street: "Rue de Rivoli"},
+		Tags:    []string{"a", "b"},
+		Secret:  "ignored",
+	}
+	assert.NoError(t, db.Set(&profile))
+
+	key := "grm:profiles:1"
+	assert.Equal(t, "Alice", s.HGet(key, "display_name"))
+	assert.Equal(t, "30", s.HGet(key, "age"))
+	assert.Equal(t, "9.5", s.HGet(key, "score"))
+	assert.Equal(t, "true", s.HGet(key, "active"))
+	assert.Equal(t, `{"City":"Paris","Street":"Rue de Rivoli"}`, s.HGet(key, "address"))
+	assert.Equal(t, profile.CreatedAt.Format(time.RFC3339Nano), s.HGet(key, "created_at"))
+	fields, _ := s.HKeys(key)
+	assert.NotContains(t, fields, "nickname")
+	assert.NotContains(t, fields, "secret")
+
+	fetched := Profile{ID: 1}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, "Alice", fetched.Name)
+	assert.Equal(t, 30, fetched.Age)
+	assert.Equal(t, 9.5, fetched.Score)
+	assert.True(t, fetched.Active)
+	assert.Nil(t, fetched.Nickname)
+	assert.Equal(t, profile.Address, fetched.Address)
+	assert.Equal(t, profile.Tags, fetched.Tags)
+	assert.Empty(t, fetched.Secret)
+	assert.True(t, profile.CreatedAt.Equal(fetched.CreatedAt))
+
+	// 重新写入时，被置空的字段会被删除
+	nickname := "Al"
+	profile.Nickname = &nickname
+	assert.NoError(t, db.Set(&profile))
+	assert.Equal(t, "Al", s.HGet(key, "nickname"))
+
+	profile.Nickname = nil
+	assert.NoError(t, db.Set(&profile))
+	fields, _ = s.HKeys(key)
+	assert.NotContains(t, fields, "nickname")
+}
+
+// 测试 Hash 模式的批量、TTL 和删除
+func TestHashStorageBatch(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(StorageHash))
+
+	profiles := []Profile{{ID: 1, Name: "Alice"}, {ID: 2, Name: "Bob"}}
+	assert.NoError(t, db.Set(&profiles, WithTTL(time.Minute)))
+	assert.Equal(t, time.Minute, s.TTL("grm:profiles:1"))
+
+	fetched := []Profile{{ID: 1}, {ID: 2}, {ID: 3}}
+	err := db.Get(&fetched)
+	assert.Equal(t, "Alice", fetched[0].Name)
+	assert.Equal(t, "Bob", fetched[1].Name)
+	assert.IsType(t, &PartialError{}, err)
+	assert.Contains(t, err.(*PartialError).Errors, "grm:profiles:3")
+
+	assert.NoError(t, db.Delete(&profiles))
+	assert.False(t, s.Exists("grm:profiles:1"))
+}
+
+// 测试模型通过 StorageModer 声明存储模式
+func TestStorageModer(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	assert.NoError(t, db.Set(&Session{ID: "s1", Token: "t"}))
+	assert.Equal(t, "t", s.HGet("grm:sessions:s1", "token"))
+
+	assert.NoError(t, db.Set(&TestUser{ID: 1, Name: "Alice"}))
+	_, err := s.Get("grm:test_users:1")
+	assert.NoError(t, err)
+
+	fetched := Session{ID: "s1"}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, "t", fetched.Token)
+}
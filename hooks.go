@@ -0,0 +1,124 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+)
+
+// BeforeSaver 由模型实现，在 Set、Create、Update 写入前对每个元素调用，返回错误时不会写入任何内容
+//
+//	func (u *User) BeforeSave(ctx context.Context, db *grm.DB) error {
+//		u.Email = strings.ToLower(u.Email)
+//		return nil
+//	}
+type BeforeSaver interface {
+	BeforeSave(ctx context.Context, db *DB) error
+}
+
+// AfterSaver 由模型实现，在写入成功后对每个写入成功的元素调用
+type AfterSaver interface {
+	AfterSave(ctx context.Context, db *DB) error
+}
+
+// BeforeCreator 由模型实现，在 Create 写入前对每个元素调用（在 BeforeSave 之后、生成主键之前）
+type BeforeCreator interface {
+	BeforeCreate(ctx context.Context, db *DB) error
+}
+
+// BeforeDeleter 由模型实现，在 Delete 删除前对每个元素调用，返回错误时不会删除任何记录
+type BeforeDeleter interface {
+	BeforeDelete(ctx context.Context, db *DB) error
+}
+
+// AfterDeleter 由模型实现，在删除成功后对每个元素调用
+type AfterDeleter interface {
+	AfterDelete(ctx context.Context, db *DB) error
+}
+
+// AfterFinder 由模型实现，在 Get、Find 和 Scan 读取成功后对每个元素调用
+type AfterFinder interface {
+	AfterFind(ctx context.Context, db *DB) error
+}
+
+// hook 标识一种生命周期钩子
+type hook int
+
+const (
+	hookBeforeSave hook = iota
+	hookAfterSave
+	hookBeforeCreate
+	hookBeforeDelete
+	hookAfterDelete
+	hookAfterFind
+)
+
+// hookTypes 是每种钩子对应的接口类型
+var hookTypes = map[hook]reflect.Type{
+	hookBeforeSave:   reflect.TypeOf((*BeforeSaver)(nil)).Elem(),
+	hookAfterSave:    reflect.TypeOf((*AfterSaver)(nil)).Elem(),
+	hookBeforeCreate: reflect.TypeOf((*BeforeCreator)(nil)).Elem(),
+	hookBeforeDelete: reflect.TypeOf((*BeforeDeleter)(nil)).Elem(),
+	hookAfterDelete:  reflect.TypeOf((*AfterDeleter)(nil)).Elem(),
+	hookAfterFind:    reflect.TypeOf((*AfterFinder)(nil)).Elem(),
+}
+
+// hasHook 判断模型是否实现了钩子
+func (s *schema) hasHook(h hook) bool {
+	return reflect.PointerTo(s.Type).Implements(hookTypes[h])
+}
+
+// callHook 调用单个元素的钩子，调用前需通过 hasHook 确认模型实现了该钩子
+func (db *DB) callHook(ctx context.Context, h hook, elem reflect.Value) error {
+	model := elem.Addr().Interface()
+	switch h {
+	case hookBeforeSave:
+		return model.(BeforeSaver).BeforeSave(ctx, db)
+	case hookAfterSave:
+		return model.(AfterSaver).AfterSave(ctx, db)
+	case hookBeforeCreate:
+		return model.(BeforeCreator).BeforeCreate(ctx, db)
+	case hookBeforeDelete:
+		return model.(BeforeDeleter).BeforeDelete(ctx, db)
+	case hookAfterDelete:
+		return model.(AfterDeleter).AfterDelete(ctx, db)
+	case hookAfterFind:
+		return model.(AfterFinder).AfterFind(ctx, db)
+	}
+	return nil
+}
+
+// callHooks 依次调用每个元素的钩子，遇到错误时停止
+func (db *DB) callHooks(ctx context.Context, s *schema, h hook, elements []reflect.Value) error {
+	if !s.hasHook(h) {
+		return nil
+	}
+	for _, elem := range elements {
+		if err := db.callHook(ctx, h, elem); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callAfterHooks 对操作成功的元素调用钩子，err 为操作的结果，keys 为各元素的 Key。
+// err 为 PartialError 时跳过其中失败的 Key，为其他错误时不调用钩子
+func (db *DB) callAfterHooks(ctx context.Context, s *schema, h hook, elements []reflect.Value, keys []string, err error) error {
+	if !s.hasHook(h) {
+		return err
+	}
+
+	var partial *PartialError
+	if err != nil && !errors.As(err, &partial) {
+		return err
+	}
+	for i, elem := range elements {
+		if partial != nil && partial.Errors[keys[i]] != nil {
+			continue
+		}
+		if herr := db.callHook(ctx, h, elem); herr != nil {
+			return herr
+		}
+	}
+	return err
+}
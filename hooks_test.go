@@ -0,0 +1,107 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// hookCalls 记录钩子的调用顺序
+var hookCalls []string
+
+type Subscriber struct {
+	ID    uint
+	Email string
+	Token string `grm:"-"`
+}
+
+func (u *Subscriber) BeforeSave(ctx context.Context, db *DB) error {
+	hookCalls = append(hookCalls, "BeforeSave")
+	if u.Email == "" {
+		return errors.New("email is required")
+	}
+	u.Email = strings.ToLower(u.Email)
+	return nil
+}
+
+func (u *Subscriber) BeforeCreate(ctx context.Context, db *DB) error {
+	hookCalls = append(hookCalls, "BeforeCreate")
+	return nil
+}
+
+func (u *Subscriber) AfterSave(ctx context.Context, db *DB) error {
+	hookCalls = append(hookCalls, "AfterSave")
+	return nil
+}
+
+func (u *Subscriber) BeforeDelete(ctx context.Context, db *DB) error {
+	hookCalls = append(hookCalls, "BeforeDelete")
+	return nil
+}
+
+func (u *Subscriber) AfterDelete(ctx context.Context, db *DB) error {
+	hookCalls = append(hookCalls, "AfterDelete")
+	return nil
+}
+
+func (u *Subscriber) AfterFind(ctx context.Context, db *DB) error {
+	hookCalls = append(hookCalls, "AfterFind")
+	u.Token = "t" + u.Email
+	return nil
+}
+
+// 测试 Set、Create、Get 和 Delete 调用生命周期钩子
+func TestHooks(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	hookCalls = nil
+	assert.NoError(t, db.Set(&Subscriber{ID: 1, Email: "A@B.C"}))
+	assert.Equal(t, []string{"BeforeSave", "AfterSave"}, hookCalls)
+
+	hookCalls = nil
+	assert.NoError(t, db.Create(&Subscriber{ID: 2, Email: "d@e.f"}))
+	assert.Equal(t, []string{"BeforeSave", "BeforeCreate", "AfterSave"}, hookCalls)
+
+	hookCalls = nil
+	fetched := Subscriber{ID: 1}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, "a@b.c", fetched.Email)
+	assert.Equal(t, "ta@b.c", fetched.Token)
+	assert.Equal(t, []string{"AfterFind"}, hookCalls)
+
+	// 读取失败的元素不调用 AfterFind
+	hookCalls = nil
+	batch := []Subscriber{{ID: 1}, {ID: 3}}
+	assert.ErrorIs(t, db.Get(&batch), ErrNotFound)
+	assert.Equal(t, []string{"AfterFind"}, hookCalls)
+
+	hookCalls = nil
+	assert.NoError(t, db.Delete(&Subscriber{ID: 1}))
+	assert.Equal(t, []string{"BeforeDelete", "AfterDelete"}, hookCalls)
+}
+
+// 测试 Before 钩子返回错误时不写入任何内容
+func TestHookAbort(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	hookCalls = nil
+	subscribers := []Subscriber{{ID: 1, Email: "a@b.c"}, {ID: 2}}
+	assert.EqualError(t, db.Set(&subscribers), "email is required")
+	assert.Empty(t, s.Keys())
+	assert.NotContains(t, hookCalls, "AfterSave")
+
+	// Create 中已存在的元素不调用 AfterSave
+	assert.NoError(t, db.Set(&Subscriber{ID: 1, Email: "a@b.c"}))
+	hookCalls = nil
+	assert.ErrorIs(t, db.Create(&[]Subscriber{{ID: 1, Email: "x@y.z"}, {ID: 2, Email: "d@e.f"}}), ErrAlreadyExists)
+	assert.Equal(t, []string{"BeforeSave", "BeforeSave", "BeforeCreate", "BeforeCreate", "AfterSave"}, hookCalls)
+}
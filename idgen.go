@@ -0,0 +1,205 @@
+package grm
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// IDGenerator 为主键为零值的模型生成主键，由 Set 和 Create 在计算 Key 之前调用
+type IDGenerator interface {
+	// NextIDs 为 model 类型的 n 条记录生成主键，返回值按 assignValue 的规则赋给主键字段，
+	// 字符串也可以赋给实现了 encoding.TextUnmarshaler 的主键类型
+	NextIDs(ctx context.Context, db *DB, model interface{}, n int) ([]interface{}, error)
+}
+
+// 内置主键生成器实例
+var (
+	// SequenceGenerator 使用 Redis INCRBY 为每个模型维护递增序列，如 "grm:seq:users"
+	SequenceGenerator IDGenerator = &sequenceGenerator{}
+	// UUIDv7Generator 生成按时间排序的 UUIDv7 字符串
+	UUIDv7Generator IDGenerator = &uuidV7Generator{}
+	// ULIDGenerator 生成按时间排序的 26 位 ULID 字符串
+	ULIDGenerator IDGenerator = &ulidGenerator{}
+)
+
+// generateIDs 为主键为零值的元素生成主键，只支持单主键的模型
+func (db *DB) generateIDs(ctx context.Context, s *schema, elements []reflect.Value) error {
+	if db.idGenerator == nil || len(s.PrimaryKeys) != 1 {
+		return nil
+	}
+
+	pk := s.PrimaryKeys[0]
+	var pending []reflect.Value
+	for _, elem := range elements {
+		if elem.FieldByIndex(pk.Index).IsZero() {
+			pending = append(pending, elem)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids, err := db.idGenerator.NextIDs(ctx, db, pending[0].Addr().Interface(), len(pending))
+	if err != nil {
+		return err
+	}
+	if len(ids) != len(pending) {
+		return fmt.Errorf("id generator returned %d ids, expected %d", len(ids), len(pending))
+	}
+	for i, elem := range pending {
+		fv, id := elem.FieldByIndex(pk.Index), reflect.ValueOf(ids[i])
+		switch {
+		case id.Kind() == reflect.String && fv.Kind() != reflect.String:
+			err = setFieldFromString(fv, id.String())
+		case fv.Kind() == reflect.String && id.CanInt():
+			// 整数主键写入字符串字段（如 grm.Model 的 ID）时格式化为十进制
+			fv.SetString(strconv.FormatInt(id.Int(), 10))
+		case fv.Kind() == reflect.String && id.CanUint():
+			fv.SetString(strconv.FormatUint(id.Uint(), 10))
+		default:
+			err = assignValue(fv, ids[i])
+		}
+		if err != nil {
+			return fmt.Errorf("field %s: %v", pk.Name, err)
+		}
+	}
+	return nil
+}
+
+type sequenceGenerator struct{}
+
+func (g *sequenceGenerator) NextIDs(ctx context.Context, db *DB, model interface{}, n int) ([]interface{}, error) {
+	s, err := parseSchema(reflect.TypeOf(model).Elem())
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := db.client.IncrBy(ctx, metaKey(db.namingStrategy, s, "seq"), int64(n)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]interface{}, n)
+	for i := range ids {
+		id := last - int64(n) + int64(i) + 1
+		if s.PrimaryKeys[0].Type.Kind() == reflect.String {
+			ids[i] = fmt.Sprint(id)
+		} else {
+			ids[i] = id
+		}
+	}
+	return ids, nil
+}
+
+type uuidV7Generator struct{}
+
+func (g *uuidV7Generator) NextIDs(ctx context.Context, db *DB, model interface{}, n int) ([]interface{}, error) {
+	ids := make([]interface{}, n)
+	for i := range ids {
+		var b [16]byte
+		if _, err := rand.Read(b[6:]); err != nil {
+			return nil, err
+		}
+		putMillis(b[:6], time.Now())
+		b[6] = b[6]&0x0f | 0x70 // 版本 7
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 变体
+
+		h := hex.EncodeToString(b[:])
+		ids[i] = h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+	}
+	return ids, nil
+}
+
+// crockford 是 ULID 使用的 Crockford Base32 字母表
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+type ulidGenerator struct{}
+
+func (g *ulidGenerator) NextIDs(ctx context.Context, db *DB, model interface{}, n int) ([]interface{}, error) {
+	ids := make([]interface{}, n)
+	for i := range ids {
+		var b [16]byte
+		if _, err := rand.Read(b[6:]); err != nil {
+			return nil, err
+		}
+		putMillis(b[:6], time.Now())
+
+		// 128 位按 5 位一组编码为 26 个字符，首字符只有 3 位
+		var out [26]byte
+		for j := 25; j >= 0; j-- {
+			bit := (25 - j) * 5 // 当前字符最低位在 128 位中的偏移
+			var v byte
+			for k := 0; k < 5; k++ {
+				if pos := bit + k; pos < 128 && b[15-pos/8]>>(pos%8)&1 == 1 {
+					v |= 1 << k
+				}
+			}
+			out[j] = crockford[v]
+		}
+		ids[i] = string(out[:])
+	}
+	return ids, nil
+}
+
+// putMillis 将 Unix 毫秒时间戳按大端序写入 6 个字节
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// snowflakeEpoch 是雪花算法的起始时间（2020-01-01 UTC）
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+type snowflakeGenerator struct {
+	mu       sync.Mutex
+	node     int64
+	lastMs   int64
+	sequence int64
+}
+
+// NewSnowflakeGenerator 返回雪花算法主键生成器：41 位毫秒时间戳、10 位节点 ID、12 位序列号。
+// node 的取值范围为 [0, 1023]，同时运行的每个进程应使用不同的 node
+func NewSnowflakeGenerator(node int64) (IDGenerator, error) {
+	if node < 0 || node > 1023 {
+		return nil, fmt.Errorf("snowflake node must be between 0 and 1023, got %d", node)
+	}
+	return &snowflakeGenerator{node: node}, nil
+}
+
+func (g *snowflakeGenerator) NextIDs(ctx context.Context, db *DB, model interface{}, n int) ([]interface{}, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ids := make([]interface{}, n)
+	for i := range ids {
+		ms := time.Since(snowflakeEpoch).Milliseconds()
+		if ms < g.lastMs {
+			// 时钟回拨时沿用上一次的时间戳
+			ms = g.lastMs
+		}
+		if ms == g.lastMs {
+			g.sequence = (g.sequence + 1) & 0xfff
+			if g.sequence == 0 {
+				// 同一毫秒内序列号用尽，等待下一毫秒
+				for ms <= g.lastMs {
+					time.Sleep(100 * time.Microsecond)
+					ms = time.Since(snowflakeEpoch).Milliseconds()
+				}
+			}
+		} else {
+			g.sequence = 0
+		}
+		g.lastMs = ms
+		ids[i] = ms<<22 | g.node<<12 | g.sequence
+	}
+	return ids, nil
+}
@@ -0,0 +1,109 @@
+package grm
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Ticket struct {
+	ID    uint64
+	Title string
+}
+
+type Note struct {
+	ID   string
+	Body string
+}
+
+// 测试 Set 使用 Redis 序列为零值主键生成主键
+func TestSequenceGenerator(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()}, WithIDGenerator(SequenceGenerator))
+
+	tickets := []Ticket{{Title: "a"}, {ID: 100, Title: "b"}, {Title: "c"}}
+	assert.NoError(t, db.Set(&tickets))
+	assert.Equal(t, []uint64{1, 100, 2}, []uint64{tickets[0].ID, tickets[1].ID, tickets[2].ID})
+	assert.True(t, s.Exists("grm:tickets:1"))
+	assert.True(t, s.Exists("grm:tickets:2"))
+	assert.False(t, s.Exists("grm:tickets:0"))
+
+	ticket := Ticket{Title: "d"}
+	assert.NoError(t, db.Create(&ticket))
+	assert.Equal(t, uint64(3), ticket.ID)
+	seq, _ := s.Get("grm:seq:tickets")
+	assert.Equal(t, "3", seq)
+
+	// 字符串主键同样可以使用序列
+	note := Note{Body: "x"}
+	assert.NoError(t, db.Set(&note))
+	assert.Equal(t, "1", note.ID)
+
+	// Update 不生成主键
+	assert.ErrorIs(t, db.Update(&Ticket{Title: "e"}), ErrNotFound)
+	seq, _ = s.Get("grm:seq:tickets")
+	assert.Equal(t, "3", seq)
+}
+
+// 测试 UUIDv7 和 ULID 的格式与顺序
+func TestTimeOrderedGenerators(t *testing.T) {
+	ctx := context.Background()
+
+	ids, err := UUIDv7Generator.NextIDs(ctx, nil, &Note{}, 100)
+	assert.NoError(t, err)
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	for _, id := range ids {
+		assert.Regexp(t, uuid, id)
+	}
+
+	ids, err = ULIDGenerator.NextIDs(ctx, nil, &Note{}, 100)
+	assert.NoError(t, err)
+	ulid := regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+	for _, id := range ids {
+		assert.Regexp(t, ulid, id)
+	}
+
+	// 时间戳部分单调不减
+	prefixes := make([]string, len(ids))
+	for i, id := range ids {
+		prefixes[i] = id.(string)[:10]
+	}
+	assert.True(t, sort.StringsAreSorted(prefixes))
+}
+
+// 测试雪花算法生成的主键唯一且递增
+func TestSnowflakeGenerator(t *testing.T) {
+	_, err := NewSnowflakeGenerator(1024)
+	assert.Error(t, err)
+
+	g, err := NewSnowflakeGenerator(7)
+	assert.NoError(t, err)
+	ids, err := g.NextIDs(context.Background(), nil, &Ticket{}, 10000)
+	assert.NoError(t, err)
+
+	for i := 1; i < len(ids); i++ {
+		assert.Greater(t, ids[i].(int64), ids[i-1].(int64))
+	}
+	assert.Equal(t, int64(7), ids[0].(int64)>>12&0x3ff)
+
+	s := setupTestRedis()
+	defer s.Close()
+	db, _ := Open(&Options{Addr: s.Addr()}, WithIDGenerator(g))
+	ticket := Ticket{Title: "a"}
+	assert.NoError(t, db.Set(&ticket))
+	assert.NotZero(t, ticket.ID)
+
+	// grm.Model 的 ID 为字符串，整数主键格式化为十进制
+	article := Article{Title: "a"}
+	assert.NoError(t, db.Set(&article))
+	id, err := strconv.ParseInt(article.ID, 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id>>12&0x3ff)
+	assert.True(t, s.Exists("grm:articles:"+article.ID))
+}
@@ -0,0 +1,340 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// indexKey 返回字段值对应的索引 Key，如 "grm:idx:users:email:a@b.c"，集合成员为记录的主键
+func (db *DB) indexKey(s *schema, f *field, value string) string {
+	return metaKey(db.namingStrategy, s, "idx", f.Column, value)
+}
+
+// sortedIndexKey 返回字段的有序索引 Key，如 "grm:zidx:users:age"，成员为记录的主键，分值为字段值
+func (db *DB) sortedIndexKey(s *schema, f *field) string {
+	return metaKey(db.namingStrategy, s, "zidx", f.Column)
+}
+
+// idSetKey 返回模型的主键集合 Key，如 "grm:ids:users"，模型带索引或唯一字段时与索引一同维护，用于计数和遍历
+func (db *DB) idSetKey(s *schema) string {
+	return metaKey(db.namingStrategy, s, "ids")
+}
+
+// member 返回记录在索引中的成员，即 Key 中的主键部分
+func (db *DB) member(s *schema, v reflect.Value) string {
+	return primaryKeySegment(s, v, db.namingStrategy.Separator())
+}
+
+// indexValue 返回记录的字段在索引中的值
+func (db *DB) indexValue(v reflect.Value, f *field) (string, bool) {
+	return db.encodeIndexValue(v.FieldByIndex(f.Index))
+}
+
+// encodeIndexValue 使用与 Hash 模式相同的编码生成索引值，nil 指针不建立索引
+func (db *DB) encodeIndexValue(fv reflect.Value) (string, bool) {
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		return "", false
+	}
+	data, err := db.encodeField(fv)
+	return data, err == nil
+}
+
+// queueIndexes 将索引和唯一约束的变化加入事务：从旧值的索引中移除，加入新值的索引。
+// old 或 next 无效分别表示记录原本不存在或将被删除
+func (db *DB) queueIndexes(ctx context.Context, pipe redis.Pipeliner, s *schema, member string, old, next reflect.Value) {
+	// 已软删除的记录不出现在主键集合和索引中，但仍然占用唯一字段的值
+	indexedOld, indexedNext := old, next
+	if old.IsValid() && isDeleted(s, old) {
+		indexedOld = reflect.Value{}
+	}
+	if next.IsValid() && isDeleted(s, next) {
+		indexedNext = reflect.Value{}
+	}
+
+	switch {
+	case !s.hasIDSet():
+	case indexedNext.IsValid():
+		pipe.SAdd(ctx, db.idSetKey(s), member)
+	default:
+		pipe.SRem(ctx, db.idSetKey(s), member)
+	}
+
+	for _, f := range s.Indexes {
+		var oldValue, nextValue string
+		var hasOld, hasNext bool
+		if indexedOld.IsValid() {
+			oldValue, hasOld = db.indexValue(old, f)
+		}
+		if indexedNext.IsValid() {
+			nextValue, hasNext = db.indexValue(next, f)
+		}
+
+		if hasOld && (!hasNext || oldValue != nextValue) {
+			pipe.SRem(ctx, db.indexKey(s, f, oldValue), member)
+		}
+		if hasNext {
+			pipe.SAdd(ctx, db.indexKey(s, f, nextValue), member)
+		}
+	}
+
+	for _, f := range s.SortedIndexes {
+		var hasNext bool
+		if indexedNext.IsValid() {
+			var score float64
+			if score, hasNext = indexScore(next.FieldByIndex(f.Index)); hasNext {
+				pipe.ZAdd(ctx, db.sortedIndexKey(s, f), redis.Z{Score: score, Member: member})
+			}
+		}
+		if !hasNext && indexedOld.IsValid() {
+			pipe.ZRem(ctx, db.sortedIndexKey(s, f), member)
+		}
+	}
+
+	// 唯一字段：释放旧值的占用，占用新值
+	for _, f := range s.Uniques {
+		var oldValue, nextValue string
+		var hasOld, hasNext bool
+		if old.IsValid() {
+			oldValue, hasOld = db.indexValue(old, f)
+		}
+		if next.IsValid() {
+			nextValue, hasNext = db.indexValue(next, f)
+		}
+
+		if hasOld && (!hasNext || oldValue != nextValue) {
+			pipe.Del(ctx, db.uniqueKey(s, f, oldValue))
+		}
+		if hasNext {
+			pipe.Set(ctx, db.uniqueKey(s, f, nextValue), member, 0)
+		}
+	}
+}
+
+// isScoreType 判断类型能否作为有序索引的分值
+func isScoreType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return t == timeType
+}
+
+// indexScore 返回字段在有序索引中的分值，time.Time 使用 Unix 毫秒，nil 指针不建立索引
+func indexScore(fv reflect.Value) (float64, bool) {
+	if fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			return 0, false
+		}
+		fv = fv.Elem()
+	}
+
+	switch fv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(fv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(fv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return fv.Float(), true
+	}
+	if t, ok := fv.Interface().(time.Time); ok {
+		return float64(t.UnixMilli()), true
+	}
+	return 0, false
+}
+
+// FindBy 通过带 index 标签的字段查找记录，dest 为切片指针（如 *[]User 或 *[]*User）
+//
+//	var users []User
+//	db.FindBy(&users, "Email", "a@b.c")
+func (db *DB) FindBy(dest interface{}, name string, value interface{}) error {
+	s, err := destSchema(dest)
+	if err != nil {
+		return err
+	}
+
+	f := s.lookUpFieldOrColumn(name)
+	if f == nil {
+		return fmt.Errorf("model %s has no field %q", s.Name, name)
+	}
+	if _, ok := f.Tags["index"]; !ok {
+		return fmt.Errorf("field %s of model %s is not indexed", f.Name, s.Name)
+	}
+
+	ctx := db.getContext()
+	if f.sorted() {
+		// 有序索引字段按 [value, value] 范围查找
+		if value == nil {
+			setSlice(dest, nil)
+			return nil
+		}
+		score, err := scoreBound(f, value, "")
+		if err != nil {
+			return err
+		}
+		members, err := db.rangeMembers(ctx, db.sortedIndexKey(s, f), score, score, false, 0, 0)
+		if err != nil {
+			return err
+		}
+		return db.findMembers(ctx, s, dest, members)
+	}
+
+	fv := reflect.New(f.Type).Elem()
+	if err := assignValue(fv, value); err != nil {
+		return fmt.Errorf("field %s: %v", f.Name, err)
+	}
+
+	var members []string
+	if encoded, ok := db.encodeIndexValue(fv); ok {
+		members, err = db.client.SMembers(ctx, db.indexKey(s, f, encoded)).Result()
+		if err != nil {
+			return err
+		}
+	}
+	sort.Strings(members)
+
+	return db.findMembers(ctx, s, dest, members)
+}
+
+// FindRange 通过带 index,sorted 标签的字段按范围查找记录，结果按字段值升序排列。
+// from、to 为 nil 时表示不限；from 大于 to 时按降序返回。limit <= 0 表示不限制数量
+//
+//	// 最近一小时创建的订单，按创建时间倒序，取前 20 条
+//	db.FindRange(&orders, "CreatedAt", time.Now(), time.Now().Add(-time.Hour), 20, 0)
+func (db *DB) FindRange(dest interface{}, name string, from, to interface{}, limit, offset int) error {
+	s, err := destSchema(dest)
+	if err != nil {
+		return err
+	}
+
+	f := s.lookUpFieldOrColumn(name)
+	if f == nil {
+		return fmt.Errorf("model %s has no field %q", s.Name, name)
+	}
+	if !f.sorted() {
+		return fmt.Errorf("field %s of model %s has no sorted index", f.Name, s.Name)
+	}
+
+	min, err := scoreBound(f, from, "-inf")
+	if err != nil {
+		return err
+	}
+	max, err := scoreBound(f, to, "+inf")
+	if err != nil {
+		return err
+	}
+
+	desc := from != nil && to != nil && scoreGreater(min, max)
+	if desc {
+		min, max = max, min
+	}
+	ctx := db.getContext()
+	members, err := db.rangeMembers(ctx, db.sortedIndexKey(s, f), min, max, desc, limit, offset)
+	if err != nil {
+		return err
+	}
+	return db.findMembers(ctx, s, dest, members)
+}
+
+// rangeMembers 按分值范围 [min, max] 读取有序索引的成员，desc 时按分值降序
+func (db *DB) rangeMembers(ctx context.Context, key, min, max string, desc bool, limit, offset int) ([]string, error) {
+	by := &redis.ZRangeBy{Min: min, Max: max, Offset: int64(offset), Count: int64(limit)}
+	if limit <= 0 {
+		by.Count = -1
+	}
+
+	if desc {
+		return db.client.ZRevRangeByScore(ctx, key, by).Result()
+	}
+	return db.client.ZRangeByScore(ctx, key, by).Result()
+}
+
+// scoreBound 将查询边界转换为分值，nil 时使用 unbounded
+func scoreBound(f *field, value interface{}, unbounded string) (string, error) {
+	if value == nil {
+		return unbounded, nil
+	}
+
+	fv := reflect.New(f.Type).Elem()
+	if err := assignValue(fv, value); err != nil {
+		return "", fmt.Errorf("field %s: %v", f.Name, err)
+	}
+	score, _ := indexScore(fv)
+	return strconv.FormatFloat(score, 'f', -1, 64), nil
+}
+
+// scoreGreater 比较两个 scoreBound 返回的分值
+func scoreGreater(a, b string) bool {
+	x, _ := strconv.ParseFloat(a, 64)
+	y, _ := strconv.ParseFloat(b, 64)
+	return x > y
+}
+
+// findMembers 按主键加载记录并写入 dest，索引中已不存在的记录会被忽略
+func (db *DB) findMembers(ctx context.Context, s *schema, dest interface{}, members []string) error {
+	elements, failed, err := db.loadMembers(ctx, s, members)
+	if err != nil {
+		return err
+	}
+
+	setSlice(dest, elements)
+	if len(failed) > 0 {
+		return &PartialError{Errors: failed}
+	}
+	return nil
+}
+
+// loadMembers 按主键加载记录并调用 AfterFind 钩子，保持 members 的顺序；不存在的记录（如已过期）会被忽略，其余读取失败的 Key 通过 failed 返回
+func (db *DB) loadMembers(ctx context.Context, s *schema, members []string) ([]reflect.Value, map[string]error, error) {
+	if len(members) == 0 {
+		return nil, nil, nil
+	}
+
+	elements := newElements(s, len(members))
+	for i, member := range members {
+		if err := db.setPrimaryKey(s, elements[i], member); err != nil {
+			return nil, nil, fmt.Errorf("index member %q: %v", member, err)
+		}
+	}
+
+	keys, err := db.getKeys(elements)
+	if err != nil {
+		return nil, nil, err
+	}
+	failed, err := db.load(ctx, db.client, s, elements, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	afterFind := s.hasHook(hookAfterFind)
+	found := make([]reflect.Value, 0, len(elements))
+	for i, key := range keys {
+		switch err := failed[key]; {
+		case err == nil && isDeleted(s, elements[i]) && !db.unscoped:
+			// 已软删除的记录
+		case err == nil:
+			if afterFind {
+				if err := db.callHook(ctx, hookAfterFind, elements[i]); err != nil {
+					failed[key] = err
+					continue
+				}
+			}
+			found = append(found, elements[i])
+		case errors.Is(err, ErrNotFound):
+			// 记录已过期或被删除，忽略索引中的残留成员
+			delete(failed, key)
+		}
+	}
+	return found, failed, nil
+}
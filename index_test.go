@@ -0,0 +1,194 @@
+package grm
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Customer struct {
+	ID     uint
+	Email  string  `grm:"index"`
+	Status string  `grm:"index"`
+	Team   *string `grm:"index"`
+	Name   string
+}
+
+// 测试 Set 和 Delete 维护索引
+func TestIndexMaintenance(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		customers := []Customer{
+			{ID: 1, Email: "a@b.c", Status: "active"},
+			{ID: 2, Email: "d@e.f", Status: "active"},
+		}
+		assert.NoError(t, db.Set(&customers))
+
+		members, _ := s.Members("grm:idx:customers:status:active")
+		assert.Equal(t, []string{"1", "2"}, members)
+		members, _ = s.Members("grm:idx:customers:email:a@b.c")
+		assert.Equal(t, []string{"1"}, members)
+		assert.False(t, s.Exists("grm:idx:customers:team:"))
+
+		// 修改被索引的字段会从旧索引中移除
+		customers[0].Status = "banned"
+		assert.NoError(t, db.Set(&customers[0]))
+		members, _ = s.Members("grm:idx:customers:status:active")
+		assert.Equal(t, []string{"2"}, members)
+		members, _ = s.Members("grm:idx:customers:status:banned")
+		assert.Equal(t, []string{"1"}, members)
+
+		// 部分更新同样维护索引
+		assert.NoError(t, db.Updates(&Customer{ID: 2}, map[string]interface{}{"Status": "banned"}))
+		assert.False(t, s.Exists("grm:idx:customers:status:active"))
+		members, _ = s.Members("grm:idx:customers:status:banned")
+		assert.Equal(t, []string{"1", "2"}, members)
+
+		// 删除时只需要主键，索引值从已存储的记录中读取
+		assert.NoError(t, db.Delete(&Customer{ID: 1}))
+		assert.False(t, s.Exists("grm:idx:customers:email:a@b.c"))
+		members, _ = s.Members("grm:idx:customers:status:banned")
+		assert.Equal(t, []string{"2"}, members)
+		s.Close()
+	}
+}
+
+// 测试通过索引查找记录
+func TestFindBy(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	team := "core"
+	customers := []Customer{
+		{ID: 1, Email: "a@b.c", Status: "active", Team: &team, Name: "Alice"},
+		{ID: 2, Email: "d@e.f", Status: "active", Name: "Bob"},
+		{ID: 3, Email: "g@h.i", Status: "banned", Name: "Carol"},
+	}
+	assert.NoError(t, db.Set(&customers))
+
+	var found []Customer
+	assert.NoError(t, db.FindBy(&found, "Email", "a@b.c"))
+	assert.Len(t, found, 1)
+	assert.Equal(t, "Alice", found[0].Name)
+
+	var active []*Customer
+	assert.NoError(t, db.FindBy(&active, "status", "active"))
+	names := []string{active[0].Name, active[1].Name}
+	sort.Strings(names)
+	assert.Equal(t, []string{"Alice", "Bob"}, names)
+
+	assert.NoError(t, db.FindBy(&found, "Team", "core"))
+	assert.Len(t, found, 1)
+
+	assert.NoError(t, db.FindBy(&found, "Email", "nobody@b.c"))
+	assert.Empty(t, found)
+
+	// 记录过期后索引中的残留成员会被忽略
+	s.Del("grm:customers:2")
+	assert.NoError(t, db.FindBy(&active, "Status", "active"))
+	assert.Len(t, active, 1)
+
+	assert.Error(t, db.FindBy(&found, "Name", "Alice"))
+	assert.Error(t, db.FindBy(found, "Email", "a@b.c"))
+}
+
+type Shipment struct {
+	ID        uint
+	Weight    float64   `grm:"index,sorted"`
+	Priority  *int      `grm:"index,sorted"`
+	CreatedAt time.Time `grm:"index,sorted"`
+	UpdatedAt time.Time
+}
+
+// 测试有序索引的维护
+func TestSortedIndexMaintenance(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	priority := 2
+	shipment := Shipment{ID: 1, Weight: 3.5, Priority: &priority}
+	assert.NoError(t, db.Set(&shipment))
+
+	score, _ := s.ZScore("grm:zidx:shipments:weight", "1")
+	assert.Equal(t, 3.5, score)
+	score, _ = s.ZScore("grm:zidx:shipments:created_at", "1")
+	assert.Equal(t, float64(shipment.CreatedAt.UnixMilli()), score)
+
+	shipment.Weight = 7
+	shipment.Priority = nil
+	assert.NoError(t, db.Set(&shipment))
+	score, _ = s.ZScore("grm:zidx:shipments:weight", "1")
+	assert.Equal(t, 7.0, score)
+	assert.False(t, s.Exists("grm:zidx:shipments:priority"))
+
+	assert.NoError(t, db.Delete(&shipment))
+	assert.False(t, s.Exists("grm:zidx:shipments:weight"))
+	assert.False(t, s.Exists("grm:zidx:shipments:created_at"))
+}
+
+// 测试按范围查找
+func TestFindRange(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	shipments := make([]Shipment, 0, 5)
+	for i := 1; i <= 5; i++ {
+		shipments = append(shipments, Shipment{ID: uint(i), Weight: float64(i * 10), CreatedAt: base.Add(time.Duration(i) * time.Minute)})
+	}
+	assert.NoError(t, db.Set(&shipments))
+
+	ids := func(found []Shipment) []uint {
+		result := make([]uint, 0, len(found))
+		for _, f := range found {
+			result = append(result, f.ID)
+		}
+		return result
+	}
+
+	var found []Shipment
+	assert.NoError(t, db.FindRange(&found, "Weight", 20, 40, 0, 0))
+	assert.Equal(t, []uint{2, 3, 4}, ids(found))
+
+	assert.NoError(t, db.FindRange(&found, "Weight", nil, 30, 0, 0))
+	assert.Equal(t, []uint{1, 2, 3}, ids(found))
+
+	assert.NoError(t, db.FindRange(&found, "Weight", 20, nil, 2, 1))
+	assert.Equal(t, []uint{3, 4}, ids(found))
+
+	// from 大于 to 时降序
+	assert.NoError(t, db.FindRange(&found, "CreatedAt", base.Add(time.Hour), base, 2, 0))
+	assert.Equal(t, []uint{5, 4}, ids(found))
+
+	assert.NoError(t, db.FindRange(&found, "CreatedAt", base.Add(2*time.Minute), base.Add(3*time.Minute), 0, 0))
+	assert.Equal(t, []uint{2, 3}, ids(found))
+
+	assert.NoError(t, db.FindBy(&found, "Weight", 50))
+	assert.Equal(t, []uint{5}, ids(found))
+
+	var none []Shipment
+	assert.Error(t, db.FindRange(&none, "ID", 1, 2, 0, 0))
+}
+
+// 测试有序索引只能用于数字和时间字段
+func TestSortedIndexType(t *testing.T) {
+	type Bad struct {
+		ID   uint
+		Name string `grm:"index,sorted"`
+	}
+
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+	_, err := db.getKey(&Bad{ID: 1})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a numeric or time.Time type")
+}
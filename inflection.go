@@ -0,0 +1,61 @@
+package grm
+
+import "strings"
+
+// 不可数名词，复数形式与单数相同
+var uncountables = map[string]bool{
+	"data": true, "deer": true, "equipment": true, "feedback": true, "fish": true,
+	"information": true, "metadata": true, "money": true, "news": true, "police": true,
+	"rice": true, "series": true, "sheep": true, "species": true, "staff": true,
+}
+
+// 不规则复数
+var irregulars = map[string]string{
+	"child": "children", "foot": "feet", "goose": "geese", "half": "halves",
+	"knife": "knives", "leaf": "leaves", "life": "lives", "man": "men",
+	"mouse": "mice", "ox": "oxen", "person": "people", "shelf": "shelves",
+	"thief": "thieves", "tooth": "teeth", "wife": "wives", "wolf": "wolves",
+	"woman": "women",
+}
+
+// Pluralize 返回英文单词的复数形式，snake_case 名称只对最后一个单词复数化
+//
+//	Pluralize("user")          // "users"
+//	Pluralize("category")      // "categories"
+//	Pluralize("email_address") // "email_addresses"
+func Pluralize(name string) string {
+	head, word := "", name
+	if i := strings.LastIndexByte(name, '_'); i >= 0 {
+		head, word = name[:i+1], name[i+1:]
+	}
+	return head + pluralizeWord(word)
+}
+
+func pluralizeWord(word string) string {
+	lower := strings.ToLower(word)
+	if word == "" || uncountables[lower] {
+		return word
+	}
+	if plural, ok := irregulars[lower]; ok {
+		return word[:1] + plural[1:]
+	}
+
+	switch {
+	case strings.HasSuffix(lower, "sis"):
+		// analysis → analyses
+		return word[:len(word)-2] + "es"
+	case strings.HasSuffix(lower, "s"), strings.HasSuffix(lower, "x"), strings.HasSuffix(lower, "z"),
+		strings.HasSuffix(lower, "ch"), strings.HasSuffix(lower, "sh"):
+		// address → addresses, box → boxes, match → matches
+		return word + "es"
+	case strings.HasSuffix(lower, "y") && len(lower) > 1 && !isVowel(lower[len(lower)-2]):
+		// category → categories，但 key → keys
+		return word[:len(word)-1] + "ies"
+	default:
+		return word + "s"
+	}
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
@@ -0,0 +1,155 @@
+package grm
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// getKey 生成 Redis Key，默认格式为 "grm:struct_prefix:id"，复合主键为 "grm:struct_prefix:id1:id2"
+func (db *DB) getKey(model interface{}) (string, error) {
+	// 解析模型（如 User → 前缀 "users"，主键字段 ID）
+	v := reflect.ValueOf(model).Elem()
+	s, err := parseSchema(v.Type())
+	if err != nil {
+		return "", err
+	}
+
+	return keyPrefix(db.namingStrategy, s) + primaryKeySegment(s, v, db.namingStrategy.Separator()), nil
+}
+
+// getKeys 生成每个元素的 Key
+func (db *DB) getKeys(elements []reflect.Value) ([]string, error) {
+	keys := make([]string, 0, len(elements))
+	for _, elem := range elements {
+		key, err := db.getKey(elem.Addr().Interface())
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// primaryKeySegment 生成 Key 中的主键部分
+// 单主键原样输出以兼容已有数据；复合主键的各个值会转义分隔符，保证可以被 ParseKey 还原
+func primaryKeySegment(s *schema, v reflect.Value, sep string) string {
+	if len(s.PrimaryKeys) == 1 {
+		return fmt.Sprintf("%v", v.FieldByIndex(s.PrimaryKeys[0].Index).Interface())
+	}
+
+	parts := make([]string, 0, len(s.PrimaryKeys))
+	for _, f := range s.PrimaryKeys {
+		parts = append(parts, escapeKeyPart(fmt.Sprintf("%v", v.FieldByIndex(f.Index).Interface()), sep))
+	}
+	return strings.Join(parts, sep)
+}
+
+// ParseKey 将 Key 解析回模型的主键字段，是 Key 生成的逆过程
+//
+//	var line OrderLine
+//	db.ParseKey("grm:order_lines:42:3", &line) // line.OrderID == 42, line.LineNo == 3
+func (db *DB) ParseKey(key string, model interface{}) error {
+	v := reflect.ValueOf(model)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("model must be a pointer to a struct")
+	}
+	v = v.Elem()
+
+	s, err := parseSchema(v.Type())
+	if err != nil {
+		return err
+	}
+
+	prefix := keyPrefix(db.namingStrategy, s)
+	if !strings.HasPrefix(key, prefix) {
+		return fmt.Errorf("key %q does not belong to model %s", key, s.Name)
+	}
+
+	if err := db.setPrimaryKey(s, v, strings.TrimPrefix(key, prefix)); err != nil {
+		return fmt.Errorf("key %q: %v", key, err)
+	}
+	return nil
+}
+
+// setPrimaryKey 将 Key 中的主键部分解析回主键字段，是 primaryKeySegment 的逆过程
+func (db *DB) setPrimaryKey(s *schema, v reflect.Value, segment string) error {
+	if len(s.PrimaryKeys) == 1 {
+		return setFieldFromString(v.FieldByIndex(s.PrimaryKeys[0].Index), segment)
+	}
+
+	parts := strings.Split(segment, db.namingStrategy.Separator())
+	if len(parts) != len(s.PrimaryKeys) {
+		return fmt.Errorf("got %d primary key parts, model %s expects %d", len(parts), s.Name, len(s.PrimaryKeys))
+	}
+	for i, f := range s.PrimaryKeys {
+		part, err := url.PathUnescape(parts[i])
+		if err != nil {
+			return err
+		}
+		if err := setFieldFromString(v.FieldByIndex(f.Index), part); err != nil {
+			return fmt.Errorf("field %s: %v", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// escapeKeyPart 对复合主键中的单个值进行百分号转义，转义 '%' 和分隔符中出现的字符
+func escapeKeyPart(s, sep string) string {
+	if !strings.ContainsAny(s, "%"+sep) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' || strings.IndexByte(sep, c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// setFieldFromString 将字符串解析为字段类型并赋值
+func setFieldFromString(v reflect.Value, s string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package grm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type OrderLine struct {
+	OrderID uint `grm:"primaryKey"`
+	LineNo  int  `grm:"primaryKey"`
+	SKU     string
+}
+
+type TenantUser struct {
+	Tenant string `grm:"primaryKey"`
+	UserID string `grm:"primaryKey"`
+	Name   string
+}
+
+// 测试复合主键 Key 生成
+func TestCompositeKey(t *testing.T) {
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+	key, err := db.getKey(&OrderLine{OrderID: 42, LineNo: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:order_lines:42:3", key)
+
+	// 值中的分隔符和 '%' 会被转义
+	key, err = db.getKey(&TenantUser{Tenant: "acme:eu", UserID: "100%"})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:tenant_users:acme%3Aeu:100%25", key)
+}
+
+// 测试单主键保持原样输出，兼容已有数据
+func TestSingleKeyNotEscaped(t *testing.T) {
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+	key, err := db.getKey(&Model{ID: "a:b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:models:a:b", key)
+}
+
+// 测试 ParseKey 还原主键字段
+func TestParseKey(t *testing.T) {
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+
+	var line OrderLine
+	assert.NoError(t, db.ParseKey("grm:order_lines:42:3", &line))
+	assert.Equal(t, OrderLine{OrderID: 42, LineNo: 3}, line)
+
+	original := TenantUser{Tenant: "acme:eu", UserID: "100%"}
+	key, _ := db.getKey(&original)
+	var parsed TenantUser
+	assert.NoError(t, db.ParseKey(key, &parsed))
+	assert.Equal(t, original, parsed)
+
+	var user TestUser
+	assert.NoError(t, db.ParseKey("grm:test_users:9", &user))
+	assert.Equal(t, uint32(9), user.ID)
+
+	assert.Error(t, db.ParseKey("grm:order_lines:42", &line))
+	assert.Error(t, db.ParseKey("grm:users:42:3", &line))
+	assert.Error(t, db.ParseKey("grm:order_lines:x:3", &line))
+}
+
+// 测试复合主键的读写删除
+func TestCompositeKeyCRUD(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	lines := []OrderLine{
+		{OrderID: 42, LineNo: 1, SKU: "apple"},
+		{OrderID: 42, LineNo: 2, SKU: "pear"},
+	}
+	assert.NoError(t, db.Set(&lines))
+	assert.True(t, s.Exists("grm:order_lines:42:1"))
+	assert.True(t, s.Exists("grm:order_lines:42:2"))
+
+	fetched := []OrderLine{{OrderID: 42, LineNo: 1}, {OrderID: 42, LineNo: 2}}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, "apple", fetched[0].SKU)
+	assert.Equal(t, "pear", fetched[1].SKU)
+
+	assert.NoError(t, db.Delete(&fetched[0]))
+	assert.False(t, s.Exists("grm:order_lines:42:1"))
+	assert.True(t, s.Exists("grm:order_lines:42:2"))
+}
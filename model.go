@@ -12,4 +12,5 @@ type Model struct {
 	ID        string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt *time.Time // 不为空时表示记录已被软删除
 }
@@ -0,0 +1,64 @@
+package grm
+
+import (
+	"context"
+	"time"
+)
+
+// ModifyOption 是 Modify 的配置选项
+type ModifyOption func(*modifyConfig)
+
+type modifyConfig struct {
+	retryConfig
+}
+
+// WithMaxRetries 设置 Modify 因冲突失败后的最大重试次数，默认为 10
+func WithMaxRetries(n int) ModifyOption {
+	return func(cfg *modifyConfig) {
+		cfg.maxRetries = n
+	}
+}
+
+// WithBackoff 设置 Modify 重试前的等待时间：从 base 开始每次翻倍，不超过 max，并加入随机抖动。默认为 1ms 到 100ms
+func WithBackoff(base, max time.Duration) ModifyOption {
+	return func(cfg *modifyConfig) {
+		cfg.backoff = base
+		cfg.maxBackoff = max
+	}
+}
+
+// Modify 在 WATCH/MULTI 事务中读取 model 对应的记录，交给 fn 修改后写回。
+// 其他客户端在此期间修改了记录时，重新读取并再次调用 fn，因此 fn 可能被调用多次，不应有其他副作用。
+// fn 返回错误时放弃写入并返回该错误；记录不存在时返回 ErrNotFound。成功后 model 为写入的记录。
+// 读取和写入与 tx.Get、tx.Set 相同，经过 Get 和 Set 的回调链（包括钩子和 grm:timestamps），写入时保留记录原有的过期时间
+//
+//	err := grm.Modify(ctx, db, &User{ID: 1}, func(u *User) error {
+//		u.Balance += 10
+//		return nil
+//	})
+func Modify[T any](ctx context.Context, db *DB, model *T, fn func(*T) error, opts ...ModifyOption) error {
+	cfg := &modifyConfig{retryConfig: defaultRetry}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var next *T
+	err := db.Transaction(ctx, func(tx *Tx) error {
+		// 在副本上修改，重试时从已存储的记录重新开始
+		copied := *model
+		next = &copied
+		if err := tx.Get(next); err != nil {
+			return err
+		}
+		if err := fn(next); err != nil {
+			return err
+		}
+		return tx.Set(next, WithKeepTTL())
+	}, WithWatchReads(), withRetry(cfg.retryConfig))
+	if err != nil {
+		return err
+	}
+
+	*model = *next
+	return nil
+}
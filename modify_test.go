@@ -0,0 +1,164 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+type Wallet struct {
+	ID      uint
+	Balance int
+	Owner   string `grm:"index"`
+}
+
+// 测试 Modify 读取、修改并写回记录
+func TestModify(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+		ctx := context.Background()
+
+		assert.NoError(t, db.Set(&Wallet{ID: 1, Balance: 10, Owner: "alice"}, WithTTL(time.Hour)))
+
+		wallet := Wallet{ID: 1}
+		err := Modify(ctx, db, &wallet, func(w *Wallet) error {
+			w.Balance += 5
+			w.Owner = "bob"
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 15, wallet.Balance)
+
+		fetched := Wallet{ID: 1}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, Wallet{ID: 1, Balance: 15, Owner: "bob"}, fetched)
+		assert.Equal(t, time.Hour, s.TTL("grm:wallets:1"))
+
+		// 索引随之更新
+		assert.False(t, s.Exists("grm:idx:wallets:owner:alice"))
+		members, _ := s.Members("grm:idx:wallets:owner:bob")
+		assert.Equal(t, []string{"1"}, members)
+
+		// fn 返回错误时不写入
+		errAbort := errors.New("abort")
+		err = Modify(ctx, db, &Wallet{ID: 1}, func(w *Wallet) error {
+			w.Balance = 0
+			return errAbort
+		})
+		assert.ErrorIs(t, err, errAbort)
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, 15, fetched.Balance)
+
+		// 记录不存在
+		err = Modify(ctx, db, &Wallet{ID: 2}, func(w *Wallet) error { return nil })
+		assert.ErrorIs(t, err, ErrNotFound)
+		s.Close()
+	}
+}
+
+// 测试并发 Modify 在冲突时重试，不丢失更新
+func TestModifyConcurrent(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	ctx := context.Background()
+	assert.NoError(t, db.Set(&Wallet{ID: 1}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Modify(ctx, db, &Wallet{ID: 1}, func(w *Wallet) error {
+				w.Balance++
+				return nil
+			}, WithMaxRetries(100))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	fetched := Wallet{ID: 1}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, 20, fetched.Balance)
+}
+
+// 测试 WATCH 冲突后的退避重试，Modify、部分更新等 WATCH 事务共用
+func TestRetry(t *testing.T) {
+	ctx := context.Background()
+	cfg := retryConfig{maxRetries: 3, backoff: time.Millisecond, maxBackoff: 2 * time.Millisecond}
+
+	attempts := 0
+	err := retry(ctx, cfg, func() error {
+		if attempts++; attempts < 3 {
+			return redis.TxFailedErr
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+
+	// 重试次数用尽后返回 redis.TxFailedErr
+	attempts = 0
+	err = retry(ctx, cfg, func() error {
+		attempts++
+		return redis.TxFailedErr
+	})
+	assert.ErrorIs(t, err, redis.TxFailedErr)
+	assert.Equal(t, 4, attempts)
+
+	// 其他错误不重试
+	attempts = 0
+	err = retry(ctx, cfg, func() error {
+		attempts++
+		return errors.New("boom")
+	})
+	assert.EqualError(t, err, "boom")
+	assert.Equal(t, 1, attempts)
+}
+
+// 测试 Modify 经过 Get 和 Set 的回调链和钩子
+func TestModifyCallbacks(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	ctx := context.Background()
+
+	assert.NoError(t, db.Set(&Subscriber{ID: 1, Email: "bob@example.com"}))
+	hookCalls = nil
+	sub := Subscriber{ID: 1}
+	err := Modify(ctx, db, &sub, func(u *Subscriber) error {
+		u.Email = "Bob@Example.org"
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"AfterFind", "BeforeSave", "AfterSave"}, hookCalls)
+	assert.Equal(t, "bob@example.org", sub.Email)
+
+	// 默认由 grm:timestamps 维护 UpdatedAt，替换后不再维护
+	assert.NoError(t, db.Set(&Gadget{ID: 1, Name: "lamp"}))
+	gadget := Gadget{ID: 1}
+	assert.NoError(t, Modify(ctx, db, &gadget, func(g *Gadget) error {
+		g.UpdatedAt = time.Time{}
+		return nil
+	}))
+	assert.False(t, gadget.UpdatedAt.IsZero())
+
+	assert.NoError(t, db.Callback().Set().Replace("grm:timestamps", func(stmt *Statement) {}))
+	assert.NoError(t, Modify(ctx, db, &gadget, func(g *Gadget) error {
+		g.UpdatedAt = time.Time{}
+		return nil
+	}))
+	assert.True(t, gadget.UpdatedAt.IsZero())
+	fetched := Gadget{ID: 1}
+	assert.NoError(t, db.Get(&fetched))
+	assert.True(t, fetched.UpdatedAt.IsZero())
+}
@@ -0,0 +1,91 @@
+package grm
+
+import (
+	"strings"
+
+	"github.com/kenshaw/snaker"
+)
+
+// NamingStrategy 控制 Redis Key 的布局：[命名空间][分隔符]表名[分隔符]主键
+type NamingStrategy interface {
+	// Namespace 返回 Key 的命名空间，为空时 Key 不带命名空间
+	Namespace() string
+	// TableName 由结构体名称（如 "User"）推导表名（如 "users"），包括复数化
+	TableName(structName string) string
+	// Separator 返回 Key 各部分之间的分隔符
+	Separator() string
+}
+
+// DefaultNamingStrategy 生成 "grm:users:15" 形式的 Key
+var DefaultNamingStrategy NamingStrategy = &NamingConfig{KeyNamespace: "grm", KeySeparator: ":"}
+
+// NamingConfig 是可配置的 NamingStrategy 实现
+//
+//	// 生成 "billing.user.15" 形式的 Key
+//	grm.WithNamingStrategy(&grm.NamingConfig{
+//		KeyNamespace:  "billing",
+//		KeySeparator:  ".",
+//		SingularTable: true,
+//	})
+type NamingConfig struct {
+	KeyNamespace  string                   // 命名空间，为空时 Key 不带命名空间
+	KeySeparator  string                   // 分隔符，为空时使用 ":"
+	SingularTable bool                     // 表名不做复数化，如 User → "user"
+	Pluralize     func(name string) string // 复数化函数，为空时使用英文复数规则 Pluralize
+}
+
+func (c *NamingConfig) Namespace() string {
+	return c.KeyNamespace
+}
+
+func (c *NamingConfig) TableName(structName string) string {
+	name := snaker.CamelToSnake(structName)
+	if c.SingularTable {
+		return name
+	}
+	if c.Pluralize != nil {
+		return c.Pluralize(name)
+	}
+	return Pluralize(name)
+}
+
+func (c *NamingConfig) Separator() string {
+	if c.KeySeparator == "" {
+		return ":"
+	}
+	return c.KeySeparator
+}
+
+// KeyPrefixer 由模型实现，用于覆盖由结构体名称推导的表名，命名空间和分隔符仍由 NamingStrategy 决定
+//
+//	func (Category) RedisKeyPrefix() string { return "catalog_categories" } // Key: "grm:catalog_categories:1"
+type KeyPrefixer interface {
+	RedisKeyPrefix() string
+}
+
+// keyPrefix 返回模型 Key 中主键之前的部分（含末尾分隔符），如 "grm:users:"
+func keyPrefix(naming NamingStrategy, s *schema) string {
+	return joinKey(naming, tableName(naming, s)) + naming.Separator()
+}
+
+// metaKey 返回 grm 内部使用的辅助 Key，如索引 "grm:idx:users:email:a@b.c"
+// kind 位于表名之前，保证辅助 Key 不会落入模型的 Key 前缀
+func metaKey(naming NamingStrategy, s *schema, kind string, parts ...string) string {
+	return joinKey(naming, append([]string{kind, tableName(naming, s)}, parts...)...)
+}
+
+// tableName 返回模型的表名，模型通过 KeyPrefixer 声明的优先
+func tableName(naming NamingStrategy, s *schema) string {
+	if s.KeyPrefix != "" {
+		return s.KeyPrefix
+	}
+	return naming.TableName(s.Name)
+}
+
+// joinKey 用分隔符连接命名空间和各部分
+func joinKey(naming NamingStrategy, parts ...string) string {
+	if ns := naming.Namespace(); ns != "" {
+		parts = append([]string{ns}, parts...)
+	}
+	return strings.Join(parts, naming.Separator())
+}
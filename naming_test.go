@@ -0,0 +1,133 @@
+package grm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pyNaming 模拟 Python 服务使用的 Key 布局："app/User/15"
+type pyNaming struct{}
+
+func (pyNaming) Namespace() string                  { return "app" }
+func (pyNaming) TableName(structName string) string { return structName }
+func (pyNaming) Separator() string                  { return "/" }
+
+// 测试默认命名策略与原有 Key 格式一致
+func TestDefaultNamingStrategy(t *testing.T) {
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+
+	key, err := db.getKey(&TestUser{ID: 15})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:test_users:15", key)
+}
+
+// 测试 NamingConfig 的各项配置
+func TestNamingConfig(t *testing.T) {
+	db := &DB{namingStrategy: &NamingConfig{KeyNamespace: "billing", KeySeparator: ".", SingularTable: true}}
+
+	key, err := db.getKey(&TestUser{ID: 15})
+	assert.NoError(t, err)
+	assert.Equal(t, "billing.test_user.15", key)
+
+	// 复合主键按自定义分隔符转义
+	key, err = db.getKey(&TenantUser{Tenant: "acme.eu", UserID: "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "billing.tenant_user.acme%2Eeu.1", key)
+
+	var parsed TenantUser
+	assert.NoError(t, db.ParseKey(key, &parsed))
+	assert.Equal(t, "acme.eu", parsed.Tenant)
+
+	// 零值：不带命名空间，分隔符为 ":"
+	db = &DB{namingStrategy: &NamingConfig{}}
+	key, _ = db.getKey(&TestUser{ID: 15})
+	assert.Equal(t, "test_users:15", key)
+}
+
+// 测试通过 DBOption 使用自定义命名策略
+func TestWithNamingStrategy(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()}, WithNamingStrategy(pyNaming{}))
+
+	assert.NoError(t, db.Set(&TestUser{ID: 15, Name: "Alice"}))
+	assert.True(t, s.Exists("app/TestUser/15"))
+
+	fetched := TestUser{ID: 15}
+	assert.NoError(t, db.Get(&fetched))
+	assert.Equal(t, "Alice", fetched.Name)
+
+	var parsed TestUser
+	assert.NoError(t, db.ParseKey("app/TestUser/15", &parsed))
+	assert.Equal(t, uint32(15), parsed.ID)
+
+	for _, key := range s.Keys() {
+		assert.False(t, strings.HasPrefix(key, "grm:"))
+	}
+}
+
+type Category struct {
+	ID   int
+	Name string
+}
+
+type LegacyUser struct {
+	ID int
+}
+
+func (LegacyUser) RedisKeyPrefix() string { return "legacy_accounts" }
+
+// 测试英文复数化规则
+func TestPluralize(t *testing.T) {
+	cases := map[string]string{
+		"user":          "users",
+		"category":      "categories",
+		"key":           "keys",
+		"address":       "addresses",
+		"email_address": "email_addresses",
+		"box":           "boxes",
+		"match":         "matches",
+		"person":        "people",
+		"child":         "children",
+		"analysis":      "analyses",
+		"news":          "news",
+		"order_line":    "order_lines",
+	}
+	for singular, plural := range cases {
+		assert.Equal(t, plural, Pluralize(singular), singular)
+	}
+}
+
+// 测试默认命名策略使用英文复数化，也可以恢复旧的 "+s" 规则
+func TestNamingPluralize(t *testing.T) {
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+	key, _ := db.getKey(&Category{ID: 1})
+	assert.Equal(t, "grm:categories:1", key)
+
+	db = &DB{namingStrategy: &NamingConfig{
+		KeyNamespace: "grm",
+		Pluralize:    func(name string) string { return name + "s" },
+	}}
+	key, _ = db.getKey(&Category{ID: 1})
+	assert.Equal(t, "grm:categorys:1", key)
+}
+
+// 测试模型通过 KeyPrefixer 覆盖表名
+func TestKeyPrefixer(t *testing.T) {
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+	key, err := db.getKey(&LegacyUser{ID: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:legacy_accounts:1", key)
+
+	var parsed LegacyUser
+	assert.NoError(t, db.ParseKey("grm:legacy_accounts:7", &parsed))
+	assert.Equal(t, 7, parsed.ID)
+
+	// 命名空间和分隔符仍由命名策略决定
+	db = &DB{namingStrategy: pyNaming{}}
+	key, _ = db.getKey(&LegacyUser{ID: 1})
+	assert.Equal(t, "app/legacy_accounts/1", key)
+}
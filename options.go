@@ -26,14 +26,103 @@ func WithSerializer(s Serializer) DBOption {
 	}
 }
 
+// WithNamingStrategy 设置 Key 的命名策略，默认为 DefaultNamingStrategy
+func WithNamingStrategy(n NamingStrategy) DBOption {
+	return func(db *DB) {
+		db.namingStrategy = n
+	}
+}
+
+// WithStorageMode 设置模型的默认存储模式，默认为 StorageString
+func WithStorageMode(mode StorageMode) DBOption {
+	return func(db *DB) {
+		db.storage = mode
+	}
+}
+
+// WithIDGenerator 设置主键生成器，Set 和 Create 会为主键为零值的模型生成主键，默认不生成
+func WithIDGenerator(g IDGenerator) DBOption {
+	return func(db *DB) {
+		db.idGenerator = g
+	}
+}
+
+// WithSoftDeleteTTL 设置软删除记录的保留时间，到期后 Redis 会删除该记录，默认永久保留
+func WithSoftDeleteTTL(d time.Duration) DBOption {
+	return func(db *DB) {
+		db.softDeleteTTL = d
+	}
+}
+
+// WithTTLJitter 为写入的过期时间加上随机抖动，每个 Key 的过期时间在 [ttl, ttl*(1+percent/100)) 之间，
+// 避免同时写入的大量记录在同一时刻过期
+func WithTTLJitter(percent float64) DBOption {
+	return func(db *DB) {
+		db.ttlJitter = percent
+	}
+}
+
+// GetOption 是 Get 的配置选项
+type GetOption func(*getConfig)
+
+type getConfig struct {
+	touch    time.Duration
+	touchSet bool // 调用时通过 WithTouch 指定了刷新的过期时间，覆盖模型的声明
+}
+
+// WithTouch 在读取成功后将记录的过期时间刷新为 d（滑动过期），覆盖模型的声明，WithTouch(0) 表示不刷新
+//
+//	db.Get(&session, grm.WithTouch(30*time.Minute))
+func WithTouch(d time.Duration) GetOption {
+	return func(cfg *getConfig) {
+		cfg.touch = d
+		cfg.touchSet = true
+	}
+}
+
 type SetOption func(*setConfig)
 
 type setConfig struct {
-	ttl time.Duration
+	ttl     time.Duration
+	ttlSet  bool // 调用时通过 WithTTL 指定了过期时间，覆盖模型的默认过期时间
+	keepTTL bool // 保留记录原有的过期时间，通过 WithKeepTTL 设置
+	mode    writeMode
+
+	atomic bool // 整个批次全部写入或全部不写入，通过 WithAtomic 设置
+
+	persist     bool // 写入后移除过期时间，由 Restore 使用
+	skipVersion bool // 不检查版本号，由软删除和 Restore 使用
 }
 
+// WithTTL 设置本次写入的过期时间，覆盖模型的默认过期时间，WithTTL(0) 表示不过期
 func WithTTL(d time.Duration) SetOption {
 	return func(cfg *setConfig) {
 		cfg.ttl = d
+		cfg.ttlSet = true
+		cfg.keepTTL = false
+	}
+}
+
+// WithKeepTTL 使写入保留记录原有的过期时间（String 模式使用 SET KEEPTTL），而不是重置或移除它。
+// 新创建的记录不会过期，模型的默认过期时间不生效
+//
+//	db.Set(&user, grm.WithKeepTTL())
+func WithKeepTTL() SetOption {
+	return func(cfg *setConfig) {
+		cfg.ttl = 0
+		cfg.ttlSet = false
+		cfg.keepTTL = true
+	}
+}
+
+// WithAtomic 使批量写入全部写入或全部不写入：带 TTL 的批次在 MULTI/EXEC 中写入，
+// 无 TTL 的批次使用本身即为原子的 MSET。Create、Update、部分更新以及带索引、唯一约束或版本号的模型
+// 在 WATCH 事务中检查所有元素，任一元素失败时整个批次都不写入，PartialError 中失败的元素为其原因，
+// 其余元素为 ErrBatchAborted。未指定时各元素的结果独立，失败的元素通过 PartialError 返回
+//
+//	db.Set(&users, grm.WithTTL(time.Hour), grm.WithAtomic())
+func WithAtomic() SetOption {
+	return func(cfg *setConfig) {
+		cfg.atomic = true
 	}
 }
@@ -0,0 +1,666 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUnindexedQuery 表示查询条件无法通过索引完成，需要 AllowScan 才能在内存中过滤
+var ErrUnindexedQuery = errors.New("query requires an index")
+
+// Query 是基于索引的链式查询，通过 db.Model 或 db.Where 创建
+//
+//	var users []User
+//	db.Model(&User{}).
+//		Where("Status = ?", "active").
+//		Where("Age >= ?", 18).
+//		Order("CreatedAt desc").
+//		Limit(20).Offset(40).
+//		Find(&users)
+type Query struct {
+	db        *DB
+	model     interface{}
+	conds     []condition
+	order     string
+	limit     int
+	offset    int
+	allowScan bool
+	err       error
+}
+
+// condition 是一个 Where 条件
+type condition struct {
+	name   string
+	op     string // =, !=, >, >=, <, <=, in, between
+	values []interface{}
+}
+
+// conditionPattern 匹配 "Field op ?" 形式的条件
+var conditionPattern = regexp.MustCompile(`(?i)^\s*(\w+)\s*(==|!=|<>|>=|<=|=|>|<|\bin\b|\bbetween\b)\s*(.*?)\s*$`)
+
+var betweenPattern = regexp.MustCompile(`(?i)^\?\s+and\s+\?$`)
+
+// Model 创建针对模型类型的查询
+func (db *DB) Model(model interface{}) *Query {
+	return &Query{db: db, model: model}
+}
+
+// Where 创建查询并添加条件，模型类型由 Find 的 dest 决定
+func (db *DB) Where(query string, args ...interface{}) *Query {
+	return db.Model(nil).Where(query, args...)
+}
+
+// Where 添加条件，多个条件之间为 AND 关系。支持 =、!=、>、>=、<、<=、IN 和 BETWEEN ? AND ?。
+// IN 的参数为空切片时查询结果为空
+func (q *Query) Where(query string, args ...interface{}) *Query {
+	m := conditionPattern.FindStringSubmatch(query)
+	if m == nil {
+		return q.addError(fmt.Errorf("unsupported condition %q", query))
+	}
+
+	c := condition{name: m[1], op: strings.ToLower(m[2])}
+	switch c.op {
+	case "==":
+		c.op = "="
+	case "<>":
+		c.op = "!="
+	}
+
+	placeholders := strings.Count(m[3], "?")
+	switch {
+	case c.op == "between" && (placeholders != 2 || len(args) != 2 || !betweenPattern.MatchString(m[3])):
+		return q.addError(fmt.Errorf("condition %q must be of the form \"Field BETWEEN ? AND ?\" with 2 arguments", query))
+	case c.op == "in":
+		if placeholders != 1 || len(args) != 1 || reflect.ValueOf(args[0]).Kind() != reflect.Slice {
+			return q.addError(fmt.Errorf("condition %q expects a single slice argument", query))
+		}
+		// IN 的参数为切片的元素，空切片表示没有记录能满足条件
+		values := reflect.ValueOf(args[0])
+		c.values = make([]interface{}, values.Len())
+		for i := range c.values {
+			c.values[i] = values.Index(i).Interface()
+		}
+	case c.op != "between":
+		if placeholders != 1 || strings.TrimSpace(m[3]) != "?" || len(args) != 1 {
+			return q.addError(fmt.Errorf("condition %q expects exactly one argument", query))
+		}
+	}
+	if c.op != "in" {
+		c.values = args
+	}
+
+	q.conds = append(q.conds, c)
+	return q
+}
+
+// Order 设置排序字段，如 "CreatedAt desc"，字段须带 index,sorted 标签（AllowScan 时可在内存中排序）
+func (q *Query) Order(order string) *Query {
+	q.order = order
+	return q
+}
+
+// Limit 限制返回数量，<= 0 表示不限制
+func (q *Query) Limit(limit int) *Query {
+	q.limit = max(limit, 0)
+	return q
+}
+
+// Offset 跳过前 offset 条记录，负数视为 0
+func (q *Query) Offset(offset int) *Query {
+	q.offset = max(offset, 0)
+	return q
+}
+
+// AllowScan 允许无法通过索引完成的条件和排序在内存中进行，没有可用索引时会遍历该模型的所有记录
+func (q *Query) AllowScan() *Query {
+	q.allowScan = true
+	return q
+}
+
+func (q *Query) addError(err error) *Query {
+	if q.err == nil {
+		q.err = err
+	}
+	return q
+}
+
+// Find 执行查询并将结果写入 dest（如 *[]User 或 *[]*User）
+func (q *Query) Find(dest interface{}) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	s, err := destSchema(dest)
+	if err != nil {
+		return err
+	}
+	if q.model != nil {
+		ms, err := parseSchema(reflect.Indirect(reflect.ValueOf(q.model)).Type())
+		if err != nil {
+			return err
+		}
+		if ms != s {
+			return fmt.Errorf("dest element %s does not match model %s", s.Type, ms.Type)
+		}
+	}
+
+	p, err := q.plan(s)
+	if err != nil {
+		return err
+	}
+	if p.empty {
+		setSlice(dest, nil)
+		return nil
+	}
+
+	ctx := q.db.getContext()
+	members, err := p.members(ctx)
+	if err != nil {
+		return err
+	}
+	if !p.inMemory() {
+		return q.db.findMembers(ctx, s, dest, members)
+	}
+
+	elements, failed, err := q.db.loadMembers(ctx, s, members)
+	if err != nil {
+		return err
+	}
+	if elements, err = p.filter(elements); err != nil {
+		return err
+	}
+
+	setSlice(dest, paginate(elements, q.offset, q.limit))
+	if len(failed) > 0 {
+		return &PartialError{Errors: failed}
+	}
+	return nil
+}
+
+// queryPlan 是查询到索引操作的映射
+type queryPlan struct {
+	q *Query
+	s *schema
+
+	sets    []string     // 等值条件对应的 Set 索引，通过 SINTER 求交集
+	unions  [][]string   // IN 条件对应的 Set 索引，每组通过 SUNION 求并集
+	ranges  []*scoreSpan // 有序索引字段的分值范围
+	filters []condition  // 无法使用索引的条件，在内存中过滤
+
+	orderField *field
+	desc       bool
+	sortInMem  bool // 排序字段没有有序索引，在内存中排序
+	empty      bool // 存在空的 IN 条件，结果必然为空，无需访问 Redis
+}
+
+// scoreSpan 是有序索引字段上的分值范围
+type scoreSpan struct {
+	field            *field
+	min, max         float64
+	minOpen, maxOpen bool // 是否为开区间
+}
+
+// plan 将条件映射到索引：Set 索引上的等值和 IN 条件、有序索引上的比较条件；其余条件需要 AllowScan
+func (q *Query) plan(s *schema) (*queryPlan, error) {
+	p := &queryPlan{q: q, s: s}
+
+	for _, c := range q.conds {
+		f := s.lookUpFieldOrColumn(c.name)
+		if f == nil {
+			return nil, fmt.Errorf("model %s has no field %q", s.Name, c.name)
+		}
+		if c.op == "in" && len(c.values) == 0 {
+			p.empty = true
+			continue
+		}
+
+		indexed, err := p.addIndexed(f, c)
+		if err != nil {
+			return nil, err
+		}
+		if !indexed {
+			if !q.allowScan {
+				return nil, fmt.Errorf("%w: condition on field %s of model %s (%s) cannot use an index, call AllowScan to filter in memory", ErrUnindexedQuery, f.Name, s.Name, c.op)
+			}
+			p.filters = append(p.filters, c)
+		}
+	}
+
+	if q.order != "" {
+		parts := strings.Fields(q.order)
+		if len(parts) > 2 || (len(parts) == 2 && !strings.EqualFold(parts[1], "asc") && !strings.EqualFold(parts[1], "desc")) {
+			return nil, fmt.Errorf("unsupported order %q", q.order)
+		}
+		p.orderField = s.lookUpFieldOrColumn(parts[0])
+		if p.orderField == nil {
+			return nil, fmt.Errorf("model %s has no field %q", s.Name, parts[0])
+		}
+		p.desc = len(parts) == 2 && strings.EqualFold(parts[1], "desc")
+		if !p.orderField.sorted() {
+			if !q.allowScan {
+				return nil, fmt.Errorf("%w: order by field %s of model %s requires a sorted index, call AllowScan to sort in memory", ErrUnindexedQuery, p.orderField.Name, s.Name)
+			}
+			p.sortInMem = true
+		}
+	}
+
+	if len(p.sets) == 0 && len(p.unions) == 0 && len(p.ranges) == 0 && (p.orderField == nil || p.sortInMem) && !q.allowScan && !p.empty {
+		return nil, fmt.Errorf("%w: query on model %s has no indexed condition, call AllowScan to scan all records", ErrUnindexedQuery, s.Name)
+	}
+	return p, nil
+}
+
+// addIndexed 尝试将条件映射到索引，无法使用索引时返回 false
+func (p *queryPlan) addIndexed(f *field, c condition) (bool, error) {
+	db := p.q.db
+
+	if f.sorted() {
+		if c.op == "!=" || c.op == "in" {
+			return false, nil
+		}
+
+		scores := make([]float64, 0, len(c.values))
+		for _, value := range c.values {
+			fv := reflect.New(f.Type).Elem()
+			if err := assignValue(fv, value); err != nil {
+				return false, fmt.Errorf("field %s: %v", f.Name, err)
+			}
+			score, ok := indexScore(fv)
+			if !ok {
+				return false, nil
+			}
+			scores = append(scores, score)
+		}
+
+		span := p.span(f)
+		switch c.op {
+		case "=":
+			span.tighten(scores[0], false, scores[0], false)
+		case ">":
+			span.tighten(scores[0], true, math.Inf(1), false)
+		case ">=":
+			span.tighten(scores[0], false, math.Inf(1), false)
+		case "<":
+			span.tighten(math.Inf(-1), false, scores[0], true)
+		case "<=":
+			span.tighten(math.Inf(-1), false, scores[0], false)
+		case "between":
+			span.tighten(scores[0], false, scores[1], false)
+		}
+		return true, nil
+	}
+
+	if _, ok := f.Tags["index"]; !ok || (c.op != "=" && c.op != "in") {
+		return false, nil
+	}
+
+	keys := make([]string, 0, len(c.values))
+	for _, value := range c.values {
+		fv := reflect.New(f.Type).Elem()
+		if err := assignValue(fv, value); err != nil {
+			return false, fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		encoded, ok := db.encodeIndexValue(fv)
+		if !ok {
+			return false, nil
+		}
+		keys = append(keys, db.indexKey(p.s, f, encoded))
+	}
+
+	if c.op == "=" {
+		p.sets = append(p.sets, keys[0])
+	} else {
+		p.unions = append(p.unions, keys)
+	}
+	return true, nil
+}
+
+// span 返回字段的分值范围，不存在时创建
+func (p *queryPlan) span(f *field) *scoreSpan {
+	for _, r := range p.ranges {
+		if r.field == f {
+			return r
+		}
+	}
+	r := &scoreSpan{field: f, min: math.Inf(-1), max: math.Inf(1)}
+	p.ranges = append(p.ranges, r)
+	return r
+}
+
+// tighten 与另一个范围求交集
+func (r *scoreSpan) tighten(min float64, minOpen bool, max float64, maxOpen bool) {
+	if min > r.min || (min == r.min && minOpen) {
+		r.min, r.minOpen = min, minOpen
+	}
+	if max < r.max || (max == r.max && maxOpen) {
+		r.max, r.maxOpen = max, maxOpen
+	}
+}
+
+// args 返回 ZRANGEBYSCORE 的 min、max 参数
+func (r *scoreSpan) args() (string, string) {
+	format := func(score float64, open bool) string {
+		var s string
+		switch {
+		case math.IsInf(score, -1):
+			return "-inf"
+		case math.IsInf(score, 1):
+			return "+inf"
+		default:
+			s = strconv.FormatFloat(score, 'f', -1, 64)
+		}
+		if open {
+			return "(" + s
+		}
+		return s
+	}
+	return format(r.min, r.minOpen), format(r.max, r.maxOpen)
+}
+
+// inMemory 判断是否需要先加载记录，再在内存中过滤、排序和分页
+func (p *queryPlan) inMemory() bool {
+	return len(p.filters) > 0 || p.sortInMem
+}
+
+// members 执行索引查询，返回匹配记录的主键，顺序即结果顺序
+func (p *queryPlan) members(ctx context.Context) ([]string, error) {
+	db, q := p.q.db, p.q
+
+	// 选择驱动索引：排序字段的有序索引 > 第一个范围条件 > Set 索引的交集
+	var driver *scoreSpan
+	if p.orderField != nil && !p.sortInMem {
+		driver = p.span(p.orderField)
+	} else if len(p.ranges) > 0 {
+		driver = p.ranges[0]
+	}
+
+	limit, offset := q.limit, q.offset
+	if p.inMemory() {
+		limit, offset = 0, 0
+	}
+
+	if driver != nil {
+		var rest []*scoreSpan
+		for _, r := range p.ranges {
+			if r != driver {
+				rest = append(rest, r)
+			}
+		}
+
+		// 只有 Set 索引作为附加条件时，使用 ZINTERSTORE 在服务端求交集并分页
+		if len(rest) == 0 && len(p.unions) == 0 {
+			return p.rangeIntersect(ctx, driver, limit, offset)
+		}
+
+		members, err := p.rangeIntersect(ctx, driver, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		members, err = p.intersect(ctx, members, rest, p.unions)
+		if err != nil {
+			return nil, err
+		}
+		return paginate(members, offset, limit), nil
+	}
+
+	var members []string
+	var err error
+	switch {
+	case len(p.sets) > 0:
+		members, err = db.client.SInter(ctx, p.sets...).Result()
+	case len(p.unions) > 0:
+		members, err = db.client.SUnion(ctx, p.unions[0]...).Result()
+	default:
+		// AllowScan 且没有任何索引条件时遍历所有记录
+		members, err = db.allMembers(ctx, p.s)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(members)
+
+	if len(p.sets) > 0 {
+		members, err = p.intersect(ctx, members, nil, p.unions)
+	} else if len(p.unions) > 1 {
+		members, err = p.intersect(ctx, members, nil, p.unions[1:])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return paginate(members, offset, limit), nil
+}
+
+// rangeIntersect 按分值范围读取有序索引，存在 Set 条件时先 ZINTERSTORE 到临时 Key（Set 的权重为 0，保留原分值）
+func (p *queryPlan) rangeIntersect(ctx context.Context, r *scoreSpan, limit, offset int) ([]string, error) {
+	db := p.q.db
+	min, max := r.args()
+	desc := p.desc && p.orderField == r.field
+	key := db.sortedIndexKey(p.s, r.field)
+
+	if len(p.sets) == 0 {
+		return db.rangeMembers(ctx, key, min, max, desc, limit, offset)
+	}
+
+	tmp := metaKey(db.namingStrategy, p.s, "tmp", strconv.FormatInt(time.Now().UnixNano(), 36))
+	weights := make([]float64, len(p.sets)+1)
+	weights[0] = 1
+
+	by := &redis.ZRangeBy{Min: min, Max: max, Offset: int64(offset), Count: int64(limit)}
+	if limit <= 0 {
+		by.Count = -1
+	}
+
+	var result *redis.StringSliceCmd
+	_, err := db.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZInterStore(ctx, tmp, &redis.ZStore{Keys: append([]string{key}, p.sets...), Weights: weights})
+		if desc {
+			result = pipe.ZRevRangeByScore(ctx, tmp, by)
+		} else {
+			result = pipe.ZRangeByScore(ctx, tmp, by)
+		}
+		pipe.Del(ctx, tmp)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.Val(), nil
+}
+
+// intersect 在内存中用其余范围条件和 IN 条件过滤 members，保持原有顺序
+func (p *queryPlan) intersect(ctx context.Context, members []string, ranges []*scoreSpan, unions [][]string) ([]string, error) {
+	db := p.q.db
+
+	var allowed []map[string]bool
+	for _, r := range ranges {
+		min, max := r.args()
+		result, err := db.rangeMembers(ctx, db.sortedIndexKey(p.s, r.field), min, max, false, 0, 0)
+		if err != nil {
+			return nil, err
+		}
+		allowed = append(allowed, toSet(result))
+	}
+	for _, keys := range unions {
+		result, err := db.client.SUnion(ctx, keys...).Result()
+		if err != nil {
+			return nil, err
+		}
+		allowed = append(allowed, toSet(result))
+	}
+
+	filtered := make([]string, 0, len(members))
+	for _, member := range members {
+		ok := true
+		for _, set := range allowed {
+			if !set[member] {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, member)
+		}
+	}
+	return filtered, nil
+}
+
+// filter 在内存中应用无法使用索引的条件和排序
+func (p *queryPlan) filter(elements []reflect.Value) ([]reflect.Value, error) {
+	db := p.q.db
+	filtered := make([]reflect.Value, 0, len(elements))
+	for _, elem := range elements {
+		ok := true
+		for _, c := range p.filters {
+			matched, err := db.match(p.s, elem, c)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			filtered = append(filtered, elem)
+		}
+	}
+
+	if p.sortInMem {
+		f := p.orderField
+		sort.SliceStable(filtered, func(i, j int) bool {
+			c, _ := compareValues(filtered[i].FieldByIndex(f.Index), filtered[j].FieldByIndex(f.Index))
+			if p.desc {
+				return c > 0
+			}
+			return c < 0
+		})
+	}
+	return filtered, nil
+}
+
+// match 判断记录是否满足条件
+func (db *DB) match(s *schema, v reflect.Value, c condition) (bool, error) {
+	f := s.lookUpFieldOrColumn(c.name)
+	fv := v.FieldByIndex(f.Index)
+	if fv.Kind() == reflect.Ptr && fv.IsNil() {
+		return false, nil
+	}
+
+	compare := func(value interface{}) (int, error) {
+		arg := reflect.New(f.Type).Elem()
+		if err := assignValue(arg, value); err != nil {
+			return 0, fmt.Errorf("field %s: %v", f.Name, err)
+		}
+		if c.op == "=" || c.op == "!=" || c.op == "in" {
+			a, _ := db.encodeIndexValue(fv)
+			b, _ := db.encodeIndexValue(arg)
+			if a == b {
+				return 0, nil
+			}
+			return 1, nil
+		}
+		result, ok := compareValues(fv, arg)
+		if !ok {
+			return 0, fmt.Errorf("field %s of type %s cannot be compared with %s", f.Name, f.Type, c.op)
+		}
+		return result, nil
+	}
+
+	switch c.op {
+	case "in":
+		for _, value := range c.values {
+			result, err := compare(value)
+			if err != nil {
+				return false, err
+			}
+			if result == 0 {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "between":
+		low, err := compare(c.values[0])
+		if err != nil {
+			return false, err
+		}
+		high, err := compare(c.values[1])
+		return low >= 0 && high <= 0, err
+	}
+
+	result, err := compare(c.values[0])
+	if err != nil {
+		return false, err
+	}
+	switch c.op {
+	case "=":
+		return result == 0, nil
+	case "!=":
+		return result != 0, nil
+	case ">":
+		return result > 0, nil
+	case ">=":
+		return result >= 0, nil
+	case "<":
+		return result < 0, nil
+	default:
+		return result <= 0, nil
+	}
+}
+
+// compareValues 比较两个同类型的字段值，支持数字、时间和字符串
+func compareValues(a, b reflect.Value) (int, bool) {
+	if a.Kind() == reflect.Ptr {
+		if a.IsNil() || b.IsNil() {
+			return 0, false
+		}
+		a, b = a.Elem(), b.Elem()
+	}
+
+	if x, ok := a.Interface().(time.Time); ok {
+		return x.Compare(b.Interface().(time.Time)), true
+	}
+	if x, ok := indexScore(a); ok {
+		y, _ := indexScore(b)
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+	if a.Kind() == reflect.String {
+		return strings.Compare(a.String(), b.String()), true
+	}
+	return 0, false
+}
+
+// paginate 对结果分页
+func paginate[T any](items []T, offset, limit int) []T {
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+func toSet(members []string) map[string]bool {
+	set := make(map[string]bool, len(members))
+	for _, m := range members {
+		set[m] = true
+	}
+	return set
+}
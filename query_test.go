@@ -0,0 +1,145 @@
+package grm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Player struct {
+	ID        uint
+	Status    string `grm:"index"`
+	Region    string `grm:"index"`
+	Age       int    `grm:"index,sorted"`
+	Name      string
+	CreatedAt time.Time `grm:"index,sorted"`
+}
+
+func setupPlayers(t *testing.T) *DB {
+	s := setupTestRedis()
+	t.Cleanup(s.Close)
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	players := []Player{
+		{ID: 1, Status: "active", Region: "eu", Age: 17, Name: "Alice", CreatedAt: base.Add(1 * time.Hour)},
+		{ID: 2, Status: "active", Region: "us", Age: 25, Name: "Bob", CreatedAt: base.Add(2 * time.Hour)},
+		{ID: 3, Status: "banned", Region: "eu", Age: 30, Name: "Carol", CreatedAt: base.Add(3 * time.Hour)},
+		{ID: 4, Status: "active", Region: "eu", Age: 41, Name: "Dave", CreatedAt: base.Add(4 * time.Hour)},
+		{ID: 5, Status: "active", Region: "apac", Age: 18, Name: "Eve", CreatedAt: base.Add(5 * time.Hour)},
+	}
+	assert.NoError(t, db.Set(&players))
+	return db
+}
+
+func playerIDs(players []Player) []uint {
+	ids := make([]uint, 0, len(players))
+	for _, p := range players {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// 测试等值和范围条件的组合
+func TestQueryWhere(t *testing.T) {
+	db := setupPlayers(t)
+
+	var players []Player
+	assert.NoError(t, db.Model(&Player{}).Where("Status = ?", "active").Where("Age >= ?", 18).Find(&players))
+	assert.Equal(t, []uint{5, 2, 4}, playerIDs(players))
+
+	assert.NoError(t, db.Where("Status = ?", "active").Where("Region = ?", "eu").Find(&players))
+	assert.Equal(t, []uint{1, 4}, playerIDs(players))
+
+	assert.NoError(t, db.Where("Age BETWEEN ? AND ?", 18, 30).Find(&players))
+	assert.Equal(t, []uint{5, 2, 3}, playerIDs(players))
+
+	assert.NoError(t, db.Where("Age > ?", 18).Where("Age < ?", 41).Find(&players))
+	assert.Equal(t, []uint{2, 3}, playerIDs(players))
+
+	assert.NoError(t, db.Where("Region IN ?", []string{"us", "apac"}).Find(&players))
+	assert.Equal(t, []uint{2, 5}, playerIDs(players))
+
+	// 空的 IN 条件直接返回空结果
+	assert.NoError(t, db.Where("Region IN ?", []string{}).Find(&players))
+	assert.Empty(t, players)
+	assert.NoError(t, db.Where("Status = ?", "active").Where("Region IN ?", []string{}).Order("Age desc").Find(&players))
+	assert.Empty(t, players)
+
+	assert.NoError(t, db.Where("Region in ?", []string{"eu", "us"}).Where("Age <= ?", 30).Where("CreatedAt >= ?", time.Date(2025, 1, 1, 2, 0, 0, 0, time.UTC)).Find(&players))
+	assert.Equal(t, []uint{2, 3}, playerIDs(players))
+}
+
+// 测试排序和分页
+func TestQueryOrderAndPaging(t *testing.T) {
+	db := setupPlayers(t)
+
+	var players []Player
+	assert.NoError(t, db.Model(&Player{}).Where("Status = ?", "active").Order("CreatedAt desc").Limit(2).Find(&players))
+	assert.Equal(t, []uint{5, 4}, playerIDs(players))
+
+	assert.NoError(t, db.Model(&Player{}).Where("Status = ?", "active").Order("CreatedAt desc").Limit(2).Offset(2).Find(&players))
+	assert.Equal(t, []uint{2, 1}, playerIDs(players))
+
+	assert.NoError(t, db.Model(&Player{}).Order("Age").Offset(1).Limit(3).Find(&players))
+	assert.Equal(t, []uint{5, 2, 3}, playerIDs(players))
+
+	// 负数的 Offset 和 Limit 视为 0
+	assert.NoError(t, db.Model(&Player{}).Where("Status = ?", "active").Order("CreatedAt desc").Offset(-1).Limit(-1).Find(&players))
+	assert.Equal(t, []uint{5, 4, 2, 1}, playerIDs(players))
+	assert.NoError(t, db.Model(&Player{}).Order("Age").Offset(-1).Limit(1).Find(&players))
+	assert.Equal(t, []uint{1}, playerIDs(players))
+	assert.NoError(t, db.Model(&Player{}).AllowScan().Order("ID").Offset(-1).Find(&players))
+	assert.Equal(t, []uint{1, 2, 3, 4, 5}, playerIDs(players))
+
+	assert.NoError(t, db.Where("Age >= ?", 18).Where("Region = ?", "eu").Order("age DESC").Find(&players))
+	assert.Equal(t, []uint{4, 3}, playerIDs(players))
+
+	var pointers []*Player
+	assert.NoError(t, db.Where("Status = ?", "banned").Find(&pointers))
+	assert.Equal(t, "Carol", pointers[0].Name)
+}
+
+// 测试无法使用索引的条件
+func TestQueryUnindexed(t *testing.T) {
+	db := setupPlayers(t)
+
+	var players []Player
+	err := db.Where("Name = ?", "Alice").Find(&players)
+	assert.ErrorIs(t, err, ErrUnindexedQuery)
+
+	err = db.Where("Status = ?", "active").Order("Name").Find(&players)
+	assert.ErrorIs(t, err, ErrUnindexedQuery)
+
+	err = db.Model(&Player{}).Find(&players)
+	assert.ErrorIs(t, err, ErrUnindexedQuery)
+
+	err = db.Where("Status != ?", "active").Find(&players)
+	assert.ErrorIs(t, err, ErrUnindexedQuery)
+
+	// AllowScan 时在内存中过滤和排序
+	assert.NoError(t, db.Where("Status = ?", "active").Where("Name != ?", "Bob").Order("Name desc").AllowScan().Find(&players))
+	assert.Equal(t, []uint{5, 4, 1}, playerIDs(players))
+
+	assert.NoError(t, db.Where("Name > ?", "Bob").AllowScan().Order("Name").Limit(2).Find(&players))
+	assert.Equal(t, []uint{3, 4}, playerIDs(players))
+
+	assert.NoError(t, db.Model(&Player{}).AllowScan().Order("ID").Find(&players))
+	assert.Equal(t, []uint{1, 2, 3, 4, 5}, playerIDs(players))
+}
+
+// 测试错误的查询
+func TestQueryErrors(t *testing.T) {
+	db := setupPlayers(t)
+
+	var players []Player
+	assert.Error(t, db.Where("Status LIKE ?", "a%").Find(&players))
+	assert.Error(t, db.Where("Status = ? AND Region = ?", "a", "b").Find(&players))
+	assert.Error(t, db.Where("Age BETWEEN ?", 1).Find(&players))
+	assert.Error(t, db.Where("Region IN ?", "eu").Find(&players))
+	assert.Error(t, db.Where("Unknown = ?", 1).Find(&players))
+	assert.Error(t, db.Where("Age = ?", "old").Find(&players))
+	assert.Error(t, db.Model(&TestUser{}).Where("Status = ?", "active").Find(&players))
+	assert.Error(t, db.Where("Status = ?", "active").Order("Age sideways").Find(&players))
+}
@@ -0,0 +1,142 @@
+package grm
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"reflect"
+	"strings"
+)
+
+// defaultScanCount 是 SCAN 每批的默认 COUNT
+const defaultScanCount = 100
+
+// Scan 遍历模型的所有记录，每批通过 SCAN 读取 batchSize 个 Key（COUNT 提示）并批量加载。
+// 读取失败时产出 (nil, err)，调用方可以选择继续或停止遍历
+//
+//	for user, err := range grm.Scan(ctx, db, &User{}, 100) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func Scan[T any](ctx context.Context, db *DB, model *T, batchSize int) iter.Seq2[*T, error] {
+	return func(yield func(*T, error) bool) {
+		s, err := parseSchema(reflect.TypeOf(model).Elem())
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+
+		db := db.WithContext(ctx)
+		var cursor uint64
+		for {
+			var elements []reflect.Value
+			var failed map[string]error
+			elements, failed, cursor, err = db.scanPage(ctx, s, cursor, batchSize)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+
+			for _, elem := range elements {
+				if !yield(elem.Addr().Interface().(*T), nil) {
+					return
+				}
+			}
+			for key, err := range failed {
+				if !yield(nil, fmt.Errorf("%s: %w", key, err)) {
+					return
+				}
+			}
+
+			if cursor == 0 {
+				return
+			}
+		}
+	}
+}
+
+// ScanPage 读取一批记录到 dest（如 *[]User），返回下一次调用使用的游标，游标为 0 表示遍历结束。
+// 适用于分页展示，游标可以保存后在之后的请求中继续。SCAN 的 COUNT 只是提示，单页可能为空或多于 batchSize
+//
+//	var users []User
+//	next, err := db.ScanPage(&users, cursor, 50)
+func (db *DB) ScanPage(dest interface{}, cursor uint64, batchSize int) (uint64, error) {
+	s, err := destSchema(dest)
+	if err != nil {
+		return 0, err
+	}
+
+	elements, failed, next, err := db.scanPage(db.getContext(), s, cursor, batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	setSlice(dest, elements)
+	if len(failed) > 0 {
+		return next, &PartialError{Errors: failed}
+	}
+	return next, nil
+}
+
+// scanPage 执行一次 SCAN 并加载对应的记录
+func (db *DB) scanPage(ctx context.Context, s *schema, cursor uint64, batchSize int) ([]reflect.Value, map[string]error, uint64, error) {
+	members, next, err := db.scanKeys(ctx, s, cursor, batchSize)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	elements, failed, err := db.loadMembers(ctx, s, members)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return elements, failed, next, nil
+}
+
+// scanKeys 执行一次 SCAN，返回模型 Key 中的主键部分和下一个游标
+func (db *DB) scanKeys(ctx context.Context, s *schema, cursor uint64, batchSize int) ([]string, uint64, error) {
+	if batchSize <= 0 {
+		batchSize = defaultScanCount
+	}
+
+	prefix := keyPrefix(db.namingStrategy, s)
+	keys, next, err := db.client.Scan(ctx, cursor, escapeGlob(prefix)+"*", int64(batchSize)).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	members := make([]string, 0, len(keys))
+	for _, key := range keys {
+		members = append(members, strings.TrimPrefix(key, prefix))
+	}
+	return members, next, nil
+}
+
+// scanMembers 使用 SCAN 遍历模型的所有 Key，返回 Key 中的主键部分
+func (db *DB) scanMembers(ctx context.Context, s *schema) ([]string, error) {
+	var members []string
+	var cursor uint64
+	for {
+		batch, next, err := db.scanKeys(ctx, s, cursor, defaultScanCount)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, batch...)
+		if cursor = next; cursor == 0 {
+			return members, nil
+		}
+	}
+}
+
+// escapeGlob 转义 SCAN MATCH 模式中的特殊字符
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[]\`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
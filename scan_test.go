@@ -0,0 +1,108 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试遍历模型的所有记录
+func TestScan(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	users := make([]TestUser, 0, 25)
+	for i := 1; i <= 25; i++ {
+		users = append(users, TestUser{ID: uint32(i), Name: "user"})
+	}
+	assert.NoError(t, db.Set(&users))
+	// 其他模型和索引 Key 不会被遍历到
+	assert.NoError(t, db.Set(&Customer{ID: 1, Email: "a@b.c"}))
+
+	var ids []int
+	for user, err := range Scan(context.Background(), db, &TestUser{}, 10) {
+		assert.NoError(t, err)
+		assert.Equal(t, "user", user.Name)
+		ids = append(ids, int(user.ID))
+	}
+	sort.Ints(ids)
+	assert.Len(t, ids, 25)
+	assert.Equal(t, 1, ids[0])
+	assert.Equal(t, 25, ids[24])
+
+	// 提前结束遍历
+	count := 0
+	for range Scan(context.Background(), db, &TestUser{}, 10) {
+		count++
+		if count == 3 {
+			break
+		}
+	}
+	assert.Equal(t, 3, count)
+}
+
+// 测试遍历时的解码错误
+func TestScanDecodeError(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	assert.NoError(t, db.Set(&TestUser{ID: 1, Name: "ok"}))
+	s.Set("grm:test_users:2", "not json")
+
+	var found, failed int
+	for user, err := range Scan(context.Background(), db, &TestUser{}, 10) {
+		if err != nil {
+			failed++
+			assert.Contains(t, err.Error(), "grm:test_users:2")
+			continue
+		}
+		assert.Equal(t, "ok", user.Name)
+		found++
+	}
+	assert.Equal(t, 1, found)
+	assert.Equal(t, 1, failed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, err := range Scan(ctx, db, &TestUser{}, 10) {
+		assert.True(t, errors.Is(err, context.Canceled))
+	}
+}
+
+// 测试基于游标的分页
+func TestScanPage(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	users := make([]TestUser, 0, 25)
+	for i := 1; i <= 25; i++ {
+		users = append(users, TestUser{ID: uint32(i)})
+	}
+	assert.NoError(t, db.Set(&users))
+
+	seen := make(map[uint32]bool)
+	var cursor uint64
+	pages := 0
+	for {
+		var page []*TestUser
+		next, err := db.ScanPage(&page, cursor, 10)
+		assert.NoError(t, err)
+		for _, user := range page {
+			seen[user.ID] = true
+		}
+		pages++
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+	assert.Len(t, seen, 25)
+	assert.Greater(t, pages, 1)
+}
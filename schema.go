@@ -0,0 +1,214 @@
+package grm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kenshaw/snaker"
+)
+
+// field 描述模型中的一个可导出字段
+type field struct {
+	Name       string            // Go 字段名
+	Column     string            // Hash 模式下的 field 名，默认为 snake_case，可通过 column 标签指定
+	Index      []int             // 反射索引路径，支持嵌入结构体
+	Type       reflect.Type      // 字段类型
+	Tags       map[string]string // 解析后的 grm 标签，键为小写
+	PrimaryKey bool
+}
+
+// sorted 判断字段是否带有 index,sorted 标签
+func (f *field) sorted() bool {
+	_, index := f.Tags["index"]
+	_, sorted := f.Tags["sorted"]
+	return index && sorted
+}
+
+// schema 描述一个模型类型，每个类型只解析一次
+type schema struct {
+	Type          reflect.Type
+	Name          string       // 结构体名称（如 "User"）
+	KeyPrefix     string       // 模型通过 KeyPrefixer 声明的表名，为空时由 NamingStrategy 推导
+	StorageMode   *StorageMode // 模型通过 StorageModer 声明的存储模式，为空时使用 DB 配置
+	Fields        []*field
+	PrimaryKeys   []*field // 按声明顺序排列，多个时组成复合主键
+	Indexes       []*field // 带 index 标签的字段，使用 Set 索引
+	SortedIndexes []*field // 带 index,sorted 标签的数字或时间字段，使用 ZSet 索引
+	Uniques       []*field // 带 unique 标签的字段，每个值只能被一条记录占用
+	Version       *field   // 带 version 标签的整数字段，用于乐观锁
+	DeletedAt     *field   // 类型为 time.Time 或 *time.Time 的 DeletedAt 字段，存在时 Delete 为软删除
+
+	TTL   time.Duration // 模型的默认过期时间，由 Expirer 或 ttl 标签声明，为 0 时不过期
+	Touch time.Duration // Get 读取成功后刷新的过期时间，由 SlidingExpirer 或 touch 标签声明，为 0 时不刷新
+}
+
+// schemaCache 缓存已解析的模型，键为 reflect.Type
+var schemaCache sync.Map
+
+// parseSchema 解析模型类型，结果按类型缓存
+func parseSchema(t reflect.Type) (*schema, error) {
+	if cached, ok := schemaCache.Load(t); ok {
+		return cached.(*schema), nil
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model %s must be a struct", t)
+	}
+
+	s := &schema{
+		Type: t,
+		Name: t.Name(),
+	}
+
+	if p, ok := reflect.New(t).Interface().(KeyPrefixer); ok {
+		s.KeyPrefix = p.RedisKeyPrefix()
+	}
+	if m, ok := reflect.New(t).Interface().(StorageModer); ok {
+		mode := m.RedisStorageMode()
+		s.StorageMode = &mode
+	}
+
+	var tagged []*field
+	for _, sf := range reflect.VisibleFields(t) {
+		tags := parseTag(sf.Tag.Get("grm"))
+		// ttl 和 touch 标签可以写在任意字段上，包括嵌入的结构体（如 grm.Model `grm:"ttl=10m"`）
+		if len(sf.Index) == 1 {
+			for name, dst := range map[string]*time.Duration{"ttl": &s.TTL, "touch": &s.Touch} {
+				value, ok := tags[name]
+				if !ok {
+					continue
+				}
+				d, err := parseTTL(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s %q on field %s of model %s: %v", name, value, sf.Name, t, err)
+				}
+				*dst = d
+			}
+		}
+		if !sf.IsExported() || sf.Anonymous || viaPointer(t, sf.Index) {
+			continue
+		}
+		if _, ok := tags["-"]; ok {
+			continue
+		}
+
+		f := &field{Name: sf.Name, Column: tags["column"], Index: sf.Index, Type: sf.Type, Tags: tags}
+		if f.Column == "" {
+			f.Column = snaker.CamelToSnake(sf.Name)
+		}
+		if _, ok := tags["primarykey"]; ok {
+			f.PrimaryKey = true
+			tagged = append(tagged, f)
+		}
+		if _, ok := tags["index"]; ok {
+			if _, sorted := tags["sorted"]; !sorted {
+				s.Indexes = append(s.Indexes, f)
+			} else if !isScoreType(f.Type) {
+				return nil, fmt.Errorf("sorted index on field %s of model %s requires a numeric or time.Time type, got %s", f.Name, t, f.Type)
+			} else {
+				s.SortedIndexes = append(s.SortedIndexes, f)
+			}
+		}
+		if _, ok := tags["version"]; ok {
+			if !isVersionType(f.Type) {
+				return nil, fmt.Errorf("version field %s of model %s must be an integer, got %s", f.Name, t, f.Type)
+			}
+			s.Version = f
+		}
+		if _, ok := tags["unique"]; ok {
+			s.Uniques = append(s.Uniques, f)
+		}
+		s.Fields = append(s.Fields, f)
+	}
+
+	if len(tagged) == 0 {
+		// 未声明主键时，依次回退到 ID、Id（protoc 生成的代码使用 Id）
+		for _, name := range []string{"ID", "Id"} {
+			if f := s.lookUpField(name); f != nil {
+				f.PrimaryKey = true
+				tagged = append(tagged, f)
+				break
+			}
+		}
+		if len(tagged) == 0 {
+			return nil, fmt.Errorf("model %s must have an 'ID' field or a field tagged `grm:\"primaryKey\"`", t)
+		}
+	}
+	s.PrimaryKeys = tagged
+
+	if e, ok := reflect.New(t).Interface().(Expirer); ok {
+		s.TTL = e.Expiration()
+	}
+	if e, ok := reflect.New(t).Interface().(SlidingExpirer); ok {
+		s.Touch = e.SlidingExpiration()
+	}
+
+	if f := s.lookUpField("DeletedAt"); f != nil && (f.Type == timeType || f.Type == reflect.PointerTo(timeType)) {
+		s.DeletedAt = f
+	}
+
+	actual, _ := schemaCache.LoadOrStore(t, s)
+	return actual.(*schema), nil
+}
+
+// needsWatch 判断写入时是否需要读取已存储的记录（如维护索引、唯一约束和版本号），此时写入在 WATCH 事务中进行。
+// 软删除只在 Create、Update、部分更新和 Delete 时需要读取记录，见 watched
+func (s *schema) needsWatch() bool {
+	return len(s.Indexes) > 0 || len(s.SortedIndexes) > 0 || len(s.Uniques) > 0 || s.Version != nil
+}
+
+// hasIDSet 判断模型是否维护主键集合（带索引或唯一字段时），Count 和遍历优先读取主键集合
+func (s *schema) hasIDSet() bool {
+	return len(s.Indexes) > 0 || len(s.SortedIndexes) > 0 || len(s.Uniques) > 0
+}
+
+// lookUpField 按 Go 字段名查找字段
+func (s *schema) lookUpField(name string) *field {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// lookUpFieldOrColumn 按 Go 字段名或 Hash field 名查找字段
+func (s *schema) lookUpFieldOrColumn(name string) *field {
+	if f := s.lookUpField(name); f != nil {
+		return f
+	}
+	for _, f := range s.Fields {
+		if f.Column == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// parseTag 解析 `grm:"primaryKey,index"` 形式的标签，支持 key=value
+func parseTag(tag string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		tags[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return tags
+}
+
+// viaPointer 判断字段是否经由嵌入的指针结构体提升而来，这类字段可能无法寻址
+func viaPointer(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		f := t.Field(i)
+		if f.Type.Kind() == reflect.Ptr {
+			return true
+		}
+		t = f.Type
+	}
+	return false
+}
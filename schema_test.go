@@ -0,0 +1,102 @@
+package grm
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/go-redis-model/grm/example/protobuf/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+// 测试通过标签声明主键
+func TestPrimaryKeyTag(t *testing.T) {
+	type Account struct {
+		UserID string `grm:"primaryKey"`
+		Name   string
+	}
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+
+	key, err := db.getKey(&Account{UserID: "u-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:accounts:u-1", key)
+}
+
+// 测试未声明主键时回退到 ID / Id 字段
+func TestPrimaryKeyFallback(t *testing.T) {
+	type Message struct {
+		Id   int64
+		Body string
+	}
+	type Embedded struct {
+		Model
+		Name string
+	}
+	db := &DB{namingStrategy: DefaultNamingStrategy}
+
+	key, err := db.getKey(&Message{Id: 7})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:messages:7", key)
+
+	key, err = db.getKey(&Embedded{Model: Model{ID: "abc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:embeddeds:abc", key)
+
+	key, err = db.getKey(&pb.User{ID: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, "grm:users:3", key)
+}
+
+// 测试标签优先于 ID 字段
+func TestPrimaryKeyTagOverridesID(t *testing.T) {
+	type Device struct {
+		ID     int
+		Serial string `grm:"primaryKey"`
+	}
+
+	s, err := parseSchema(reflect.TypeOf(Device{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "Serial", s.PrimaryKeys[0].Name)
+	assert.False(t, s.lookUpField("ID").PrimaryKey)
+}
+
+// 测试多个主键标签组成复合主键
+func TestCompositePrimaryKeySchema(t *testing.T) {
+	type Pair struct {
+		A  int `grm:"primaryKey"`
+		ID int
+		B  int `grm:"primaryKey"`
+	}
+
+	s, err := parseSchema(reflect.TypeOf(Pair{}))
+	assert.NoError(t, err)
+	assert.Len(t, s.PrimaryKeys, 2)
+	assert.Equal(t, "A", s.PrimaryKeys[0].Name)
+	assert.Equal(t, "B", s.PrimaryKeys[1].Name)
+}
+
+// 测试主键在不同序列化器下行为一致
+func TestPrimaryKeyAcrossSerializers(t *testing.T) {
+	type Account struct {
+		UserID string `grm:"primaryKey"`
+		Name   string
+	}
+
+	for _, serializer := range []Serializer{JSONSerializer, MessagePackSerializer} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithSerializer(serializer))
+
+		assert.NoError(t, db.Set(&Account{UserID: "u-1", Name: "Alice"}))
+		assert.True(t, s.Exists("grm:accounts:u-1"))
+
+		fetched := Account{UserID: "u-1"}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "Alice", fetched.Name)
+		s.Close()
+	}
+}
+
+// 测试标签解析
+func TestParseTag(t *testing.T) {
+	tags := parseTag("primaryKey, index ,ttl=10m")
+	assert.Equal(t, map[string]string{"primarykey": "", "index": "", "ttl": "10m"}, tags)
+}
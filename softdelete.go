@@ -0,0 +1,104 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Unscoped 返回包含软删除记录的会话：Get、Exists、Scan 和 Count 可以读取或计入已软删除的记录，Delete 会真正删除记录。
+// 已软删除的记录会从索引和主键集合中移除，因此 FindBy 和 Model(...).Find 即使在 Unscoped 会话中也不会返回它们
+//
+//	db.Unscoped().Get(&user)
+//	db.Unscoped().Delete(&user)
+func (db *DB) Unscoped() *DB {
+	tx := *db
+	tx.unscoped = true
+	return &tx
+}
+
+// Restore 恢复软删除的记录：清除 DeletedAt，并移除软删除时设置的过期时间（模型有默认过期时间时重新设置为该时间）。
+// 记录不存在时返回 ErrNotFound
+func (db *DB) Restore(input interface{}) error {
+	elements, err := processBatch(input)
+	if err != nil || len(elements) == 0 {
+		return err
+	}
+
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return err
+	}
+	if s.DeletedAt == nil {
+		return errors.New("model " + s.Name + " has no DeletedAt field")
+	}
+
+	for _, elem := range elements {
+		fv := elem.FieldByIndex(s.DeletedAt.Index)
+		fv.Set(reflect.Zero(fv.Type()))
+	}
+	keys, err := db.getKeys(elements)
+	if err != nil {
+		return err
+	}
+
+	cfg := &setConfig{ttl: s.TTL, persist: true, skipVersion: true}
+	// 恢复的目标是已软删除的记录，需要将其视为存在
+	return db.Unscoped().saveWatched(db.getContext(), s, elements, keys, []*field{s.DeletedAt}, cfg)
+}
+
+// softDelete 将 DeletedAt 设置为当前时间，并从索引中移除记录。不存在的记录会被忽略
+func (db *DB) softDelete(ctx context.Context, s *schema, elements []reflect.Value, keys []string) error {
+	now := time.Now()
+	for _, elem := range elements {
+		if err := assignValue(elem.FieldByIndex(s.DeletedAt.Index), now); err != nil {
+			return err
+		}
+	}
+
+	cfg := &setConfig{ttl: db.softDeleteTTL, skipVersion: true}
+	err := db.saveWatched(ctx, s, elements, keys, []*field{s.DeletedAt}, cfg)
+
+	var partial *PartialError
+	if errors.As(err, &partial) {
+		for key, err := range partial.Errors {
+			if errors.Is(err, ErrNotFound) {
+				delete(partial.Errors, key)
+			}
+		}
+		if len(partial.Errors) == 0 {
+			return nil
+		}
+	}
+	return err
+}
+
+// isDeleted 判断记录是否已被软删除
+func isDeleted(s *schema, v reflect.Value) bool {
+	if s.DeletedAt == nil {
+		return false
+	}
+	fv := v.FieldByIndex(s.DeletedAt.Index)
+	if fv.Kind() == reflect.Ptr {
+		return !fv.IsNil() && !fv.Elem().IsZero()
+	}
+	return !fv.IsZero()
+}
+
+// exists 判断读取结果是否为存在的记录，loadErr 为读取该记录的错误。已软删除的记录视为不存在，Unscoped 时除外
+func (db *DB) exists(s *schema, stored reflect.Value, loadErr error) bool {
+	return loadErr == nil && (db.unscoped || !isDeleted(s, stored))
+}
+
+// hideDeleted 将已软删除的记录视为不存在，Unscoped 会话除外
+func (db *DB) hideDeleted(s *schema, elements []reflect.Value, keys []string, errors map[string]error) {
+	if s.DeletedAt == nil || db.unscoped {
+		return
+	}
+	for i, key := range keys {
+		if errors[key] == nil && isDeleted(s, elements[i]) {
+			errors[key] = ErrNotFound
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package grm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Post struct {
+	ID        uint
+	Title     string `grm:"index"`
+	Slug      string `grm:"unique"`
+	DeletedAt *time.Time
+}
+
+// 测试软删除的记录对 Get、Find、Scan、Exists 和 Count 不可见
+func TestSoftDelete(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		posts := []Post{{ID: 1, Title: "hello", Slug: "hello"}, {ID: 2, Title: "hello", Slug: "hello-2"}}
+		assert.NoError(t, db.Set(&posts))
+		assert.NoError(t, db.Delete(&Post{ID: 1}))
+		assert.True(t, s.Exists("grm:posts:1"))
+
+		assert.ErrorIs(t, db.Get(&Post{ID: 1}), ErrNotFound)
+		exists, _ := db.Exists(&Post{ID: 1})
+		assert.False(t, exists)
+		n, _ := db.Count(&Post{})
+		assert.Equal(t, int64(1), n)
+
+		var found []Post
+		assert.NoError(t, db.FindBy(&found, "Title", "hello"))
+		assert.Len(t, found, 1)
+		assert.Equal(t, uint(2), found[0].ID)
+
+		var scanned []Post
+		_, err := db.ScanPage(&scanned, 0, 100)
+		assert.NoError(t, err)
+		assert.Len(t, scanned, 1)
+
+		// Unscoped 可以读取软删除的记录
+		deleted := Post{ID: 1}
+		assert.NoError(t, db.Unscoped().Get(&deleted))
+		assert.Equal(t, "hello", deleted.Title)
+		assert.NotNil(t, deleted.DeletedAt)
+
+		// 软删除的记录仍然占用唯一字段的值
+		assert.ErrorIs(t, db.Set(&Post{ID: 3, Slug: "hello"}), ErrUniqueViolation)
+
+		// 删除不存在的记录不报错
+		assert.NoError(t, db.Delete(&Post{ID: 9}))
+		s.Close()
+	}
+}
+
+// 测试恢复软删除的记录
+func TestRestore(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()}, WithSoftDeleteTTL(time.Hour))
+
+	assert.NoError(t, db.Set(&Post{ID: 1, Title: "hello"}))
+	assert.NoError(t, db.Delete(&Post{ID: 1}))
+	assert.Equal(t, time.Hour, s.TTL("grm:posts:1"))
+	assert.False(t, s.Exists("grm:idx:posts:title:hello"))
+
+	assert.NoError(t, db.Restore(&Post{ID: 1}))
+	assert.Equal(t, time.Duration(0), s.TTL("grm:posts:1"))
+
+	post := Post{ID: 1}
+	assert.NoError(t, db.Get(&post))
+	assert.Equal(t, "hello", post.Title)
+	assert.Nil(t, post.DeletedAt)
+	members, _ := s.Members("grm:idx:posts:title:hello")
+	assert.Equal(t, []string{"1"}, members)
+
+	assert.ErrorIs(t, db.Restore(&Post{ID: 2}), ErrNotFound)
+}
+
+// 测试 Unscoped().Delete 真正删除记录
+func TestHardDelete(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	assert.NoError(t, db.Set(&Post{ID: 1, Title: "hello", Slug: "hello"}))
+	assert.NoError(t, db.Delete(&Post{ID: 1}))
+	assert.NoError(t, db.Unscoped().Delete(&Post{ID: 1}))
+	assert.False(t, s.Exists("grm:posts:1"))
+	assert.False(t, s.Exists("grm:uniq:posts:slug:hello"))
+
+	// 事务中的 Delete 同样为软删除
+	assert.NoError(t, db.Set(&Post{ID: 2, Title: "hi"}))
+	err := db.Transaction(context.Background(), func(tx *Tx) error {
+		return tx.Delete(&Post{ID: 2})
+	})
+	assert.NoError(t, err)
+	assert.True(t, s.Exists("grm:posts:2"))
+	assert.ErrorIs(t, db.Get(&Post{ID: 2}), ErrNotFound)
+}
+
+// 测试 Create 和 Update 将已软删除的记录视为不存在
+func TestWriteModesSoftDeleted(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		assert.NoError(t, db.Set(&Post{ID: 1, Title: "hello", Slug: "hello"}))
+		assert.NoError(t, db.Delete(&Post{ID: 1}))
+
+		// Update 和 Updates 不会恢复已软删除的记录
+		assert.ErrorIs(t, db.Update(&Post{ID: 1, Title: "fresh"}), ErrNotFound)
+		assert.ErrorIs(t, db.Updates(&Post{ID: 1}, map[string]interface{}{"Title": "fresh"}), ErrNotFound)
+		assert.ErrorIs(t, db.Get(&Post{ID: 1}), ErrNotFound)
+
+		// Create 可以重新创建，并沿用记录自己的唯一值
+		assert.NoError(t, db.Create(&Post{ID: 1, Title: "again", Slug: "hello"}))
+		post := Post{ID: 1}
+		assert.NoError(t, db.Get(&post))
+		assert.Equal(t, "again", post.Title)
+
+		// 事务中的写入同样如此
+		assert.NoError(t, db.Delete(&Post{ID: 1}))
+		err := db.Transaction(context.Background(), func(tx *Tx) error {
+			return tx.Set(&Post{ID: 1, Title: "fresh"}, withWriteMode(writeUpdate))
+		})
+		assert.ErrorIs(t, err, ErrNotFound)
+		err = db.Transaction(context.Background(), func(tx *Tx) error {
+			return tx.Set(&Post{ID: 1, Title: "tx"}, withWriteMode(writeCreate))
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, db.Get(&post))
+		assert.Equal(t, "tx", post.Title)
+		s.Close()
+	}
+}
@@ -0,0 +1,415 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TxOption 是 Transaction 的配置选项
+type TxOption func(*txConfig)
+
+type txConfig struct {
+	watchReads bool
+	retry      retryConfig
+}
+
+// WithWatchReads 使事务 WATCH 所有通过 tx.Get 读取的 Key，这些 Key 在提交前被其他客户端修改时，
+// 整个事务会重新执行（回调会被再次调用），保证读取的值在提交时仍然有效
+func WithWatchReads() TxOption {
+	return func(cfg *txConfig) {
+		cfg.watchReads = true
+	}
+}
+
+// withRetry 设置事务冲突后的重试，由 Modify 使用
+func withRetry(retry retryConfig) TxOption {
+	return func(cfg *txConfig) {
+		cfg.retry = retry
+	}
+}
+
+// Tx 是 Transaction 回调中的事务，写入在回调返回后统一提交。Tx 不能在多个 goroutine 中同时使用
+type Tx struct {
+	db      *DB
+	ctx     context.Context
+	rtx     *redis.Tx
+	cfg     *txConfig
+	queued  []func(redis.Pipeliner) error
+	watched map[string]bool
+	pending pendingWrites
+	after   []func() error // 提交成功后执行，如更新内存中的版本号和调用 After 钩子
+}
+
+// Transaction 执行 fn，fn 中通过 tx.Set 和 tx.Delete 加入的写入在一个 MULTI/EXEC 中原子提交，可以混合不同的模型。
+// fn 返回错误时放弃所有写入并返回该错误。带索引、唯一约束或版本号的模型会 WATCH 被写入的 Key，
+// 冲突时整个事务重新执行，因此 fn 可能被调用多次，不应有其他副作用。
+// 同一事务中的写入依次生效，后面的写入在前面的写入之上检查版本号和唯一约束、维护索引；tx.Get 仍然读取已存储的记录
+//
+//	err := db.Transaction(ctx, func(tx *grm.Tx) error {
+//		from, to := Account{ID: 1}, Account{ID: 2}
+//		if err := tx.Get(&from); err != nil {
+//			return err
+//		}
+//		if err := tx.Get(&to); err != nil {
+//			return err
+//		}
+//		from.Balance -= 10
+//		to.Balance += 10
+//		return tx.Set(&[]Account{from, to})
+//	}, grm.WithWatchReads())
+func (db *DB) Transaction(ctx context.Context, fn func(tx *Tx) error, opts ...TxOption) error {
+	cfg := &txConfig{retry: defaultRetry}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var tx *Tx
+	txf := func(rtx *redis.Tx) error {
+		tx = &Tx{db: db.WithContext(ctx), ctx: ctx, rtx: rtx, cfg: cfg}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if len(tx.queued) == 0 {
+			return nil
+		}
+
+		_, err := rtx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, queue := range tx.queued {
+				if err := queue(pipe); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		return err
+	}
+
+	err := retry(ctx, cfg.retry, func() error {
+		return db.client.Watch(ctx, txf)
+	})
+	if err != nil {
+		return err
+	}
+	for _, f := range tx.after {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pendingWrites 记录事务中已加入但尚未提交的写入。同一事务中后续的写入以此为准读取记录和检查唯一约束，
+// 否则多次写入同一记录时索引无法清理之前的值，多个记录也可能占用同一个唯一值
+type pendingWrites struct {
+	records map[string]reflect.Value // 记录 Key -> 提交后的记录，无效表示已删除
+	claims  map[string]string        // 唯一占用 Key -> 占用者的主键，空字符串表示已释放
+}
+
+// apply 用尚未提交的记录覆盖从 Redis 读取的 stored 和 loadErrors，p 为 nil 时不做任何事
+func (p *pendingWrites) apply(stored []reflect.Value, keys []string, loadErrors map[string]error) {
+	if p == nil {
+		return
+	}
+	for i, key := range keys {
+		record, ok := p.records[key]
+		switch {
+		case !ok:
+		case record.IsValid():
+			stored[i].Set(record)
+			delete(loadErrors, key)
+		default:
+			loadErrors[key] = ErrNotFound
+		}
+	}
+}
+
+// claim 返回唯一占用 Key 在事务中尚未提交的占用者，已释放时为空字符串；事务中未修改时 ok 为 false
+func (p *pendingWrites) claim(key string) (holder string, ok bool) {
+	if p == nil {
+		return "", false
+	}
+	holder, ok = p.claims[key]
+	return holder, ok
+}
+
+// record 记录 key 对应的记录从 old 变为 next，next 无效表示删除，与 queueIndexes 一样释放旧值的占用、占用新值
+func (p *pendingWrites) record(db *DB, s *schema, key, member string, old, next reflect.Value) {
+	if p.records == nil {
+		p.records = make(map[string]reflect.Value)
+		p.claims = make(map[string]string)
+	}
+	p.records[key] = next
+
+	for _, f := range s.Uniques {
+		var oldValue, nextValue string
+		var hasOld, hasNext bool
+		if old.IsValid() {
+			oldValue, hasOld = db.indexValue(old, f)
+		}
+		if next.IsValid() {
+			nextValue, hasNext = db.indexValue(next, f)
+		}
+
+		if hasOld && (!hasNext || oldValue != nextValue) {
+			p.claims[db.uniqueKey(s, f, oldValue)] = ""
+		}
+		if hasNext {
+			p.claims[db.uniqueKey(s, f, nextValue)] = member
+		}
+	}
+}
+
+// watch 在事务中 WATCH 尚未 WATCH 过的 keys。同一个 Key 只 WATCH 一次，
+// 避免重复 WATCH 时部分实现（如 miniredis）重新记录版本，漏掉两次 WATCH 之间的修改
+func (tx *Tx) watch(keys []string) error {
+	var pending []string
+	for _, key := range keys {
+		if !tx.watched[key] {
+			pending = append(pending, key)
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := tx.rtx.Watch(tx.ctx, pending...).Err(); err != nil {
+		return err
+	}
+	if tx.watched == nil {
+		tx.watched = make(map[string]bool)
+	}
+	for _, key := range pending {
+		tx.watched[key] = true
+	}
+	return nil
+}
+
+// afterCommit 在提交成功后对 elements 调用钩子
+func (tx *Tx) afterCommit(s *schema, h hook, elements []reflect.Value) {
+	if s.hasHook(h) {
+		tx.after = append(tx.after, func() error {
+			return tx.db.callHooks(tx.ctx, s, h, elements)
+		})
+	}
+}
+
+// statement 创建在事务中操作 input 的 Statement，input 为空时返回 nil
+func (tx *Tx) statement(input interface{}) (*Statement, error) {
+	elements, err := processBatch(input)
+	if err != nil || len(elements) == 0 {
+		return nil, err
+	}
+
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := tx.db.statement(tx.ctx, s, elements)
+	stmt.tx = tx
+	return stmt, nil
+}
+
+// Get 在事务中读取记录，参数与 DB.Get 相同。使用 WithWatchReads 时会先 WATCH 这些 Key
+func (tx *Tx) Get(input interface{}, opts ...GetOption) error {
+	cfg := &getConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	stmt, err := tx.statement(input)
+	if err != nil || stmt == nil {
+		return err
+	}
+	stmt.TTL = touchFor(stmt.schema, cfg)
+	return tx.db.callbacks.get.execute(stmt)
+}
+
+// Set 将写入加入事务，参数与 DB.Set 相同。版本号或唯一约束检查失败时返回错误，且不加入任何写入
+func (tx *Tx) Set(input interface{}, opts ...SetOption) error {
+	stmt, err := tx.statement(input)
+	if err != nil || stmt == nil {
+		return err
+	}
+	for _, opt := range opts {
+		opt(stmt.cfg)
+	}
+	stmt.TTL = tx.db.ttlFor(stmt.schema, stmt.cfg)
+	return tx.db.callbacks.set.execute(stmt)
+}
+
+// queueSet 将 stmt 的写入加入事务，是默认的 grm:save 回调在事务中的实现
+func (tx *Tx) queueSet(stmt *Statement) error {
+	s, elements, keys, cfg := stmt.schema, stmt.elements, stmt.Keys, stmt.cfg
+	cfg.ttl = stmt.TTL
+
+	if !watched(s, cfg) {
+		payloads := stmt.Payloads
+		tx.queued = append(tx.queued, func(pipe redis.Pipeliner) error {
+			for i, elem := range elements {
+				if payloads != nil {
+					pipe.SetArgs(tx.ctx, keys[i], payloads[i], tx.db.setArgs(cfg, ""))
+					continue
+				}
+				if err := tx.db.queueWrite(tx.ctx, pipe, s, keys[i], elem, nil, cfg); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if cfg.mode == writeUpsert {
+			// 条件写入是否生效要到提交时才能确定，只记录无条件的写入
+			nexts := make([]reflect.Value, len(elements))
+			for i, elem := range elements {
+				nexts[i] = reflect.New(s.Type).Elem()
+				nexts[i].Set(elem)
+			}
+			tx.recordWrites(s, elements, keys, make([]reflect.Value, len(keys)), nexts)
+		}
+		return nil
+	}
+
+	// 需要读取已存储的记录时，先 WATCH 记录 Key，保证读取的记录在提交时仍然有效
+	if err := tx.watch(keys); err != nil {
+		return err
+	}
+	stored := newElements(s, len(elements))
+	loadErrors, err := tx.db.load(tx.ctx, tx.rtx, s, stored, keys)
+	if err != nil {
+		return err
+	}
+	tx.pending.apply(stored, keys, loadErrors)
+
+	failed := make(map[string]error)
+	olds := make([]reflect.Value, len(elements))
+	for i, key := range keys {
+		err := loadErrors[key]
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			failed[key] = err
+			continue
+		}
+		if err := modeError(cfg.mode, tx.db.exists(s, stored[i], err)); err != nil {
+			failed[key] = err
+			continue
+		}
+		if err == nil {
+			olds[i] = stored[i]
+		}
+	}
+	queue, nexts, err := tx.db.prepareWrite(tx.ctx, tx.rtx, s, elements, olds, keys, nil, cfg, failed, &tx.pending)
+	if err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return &PartialError{Errors: failed}
+	}
+
+	tx.queued = append(tx.queued, queue)
+	tx.recordWrites(s, elements, keys, olds, nexts)
+	if s.Version != nil {
+		tx.after = append(tx.after, func() error {
+			for _, elem := range elements {
+				incrementVersion(elem.FieldByIndex(s.Version.Index))
+			}
+			return nil
+		})
+	}
+	return nil
+}
+
+// Delete 将删除加入事务，参数与 DB.Delete 相同
+func (tx *Tx) Delete(input interface{}) error {
+	stmt, err := tx.statement(input)
+	if err != nil || stmt == nil {
+		return err
+	}
+	return tx.db.callbacks.delete.execute(stmt)
+}
+
+// queueDelete 将 stmt 的删除加入事务，是默认的 grm:delete 回调在事务中的实现
+func (tx *Tx) queueDelete(stmt *Statement) error {
+	s, elements, keys := stmt.schema, stmt.elements, stmt.Keys
+	switch {
+	case s.DeletedAt != nil && !tx.db.unscoped:
+		return tx.softDelete(s, elements, keys)
+	case !s.needsWatch():
+		tx.queued = append(tx.queued, func(pipe redis.Pipeliner) error {
+			pipe.Del(tx.ctx, keys...)
+			return nil
+		})
+		return nil
+	default:
+		return tx.delete(s, elements, keys)
+	}
+}
+
+// delete 将删除记录和清理索引加入事务
+func (tx *Tx) delete(s *schema, elements []reflect.Value, keys []string) error {
+	if err := tx.watch(keys); err != nil {
+		return err
+	}
+	queue, olds, err := tx.db.prepareDelete(tx.ctx, tx.rtx, s, elements, keys, &tx.pending)
+	if err != nil {
+		return err
+	}
+	tx.queued = append(tx.queued, queue)
+	tx.recordWrites(s, elements, keys, olds, make([]reflect.Value, len(keys)))
+	return nil
+}
+
+// softDelete 将软删除加入事务，与 DB.Delete 的软删除相同，不存在的记录会被忽略
+func (tx *Tx) softDelete(s *schema, elements []reflect.Value, keys []string) error {
+	if err := tx.watch(keys); err != nil {
+		return err
+	}
+	stored := newElements(s, len(elements))
+	loadErrors, err := tx.db.load(tx.ctx, tx.rtx, s, stored, keys)
+	if err != nil {
+		return err
+	}
+	tx.pending.apply(stored, keys, loadErrors)
+
+	now := time.Now()
+	failed := make(map[string]error)
+	olds := make([]reflect.Value, len(elements))
+	for i, key := range keys {
+		if err := loadErrors[key]; err != nil {
+			failed[key] = err
+			continue
+		}
+		olds[i] = stored[i]
+		if err := assignValue(elements[i].FieldByIndex(s.DeletedAt.Index), now); err != nil {
+			return err
+		}
+	}
+	errs := make(map[string]error)
+	for key, err := range failed {
+		if !errors.Is(err, ErrNotFound) {
+			errs[key] = err
+		}
+	}
+	if len(errs) > 0 {
+		return &PartialError{Errors: errs}
+	}
+
+	cfg := &setConfig{ttl: tx.db.softDeleteTTL, skipVersion: true}
+	queue, nexts, err := tx.db.prepareWrite(tx.ctx, tx.rtx, s, elements, olds, keys, []*field{s.DeletedAt}, cfg, failed, &tx.pending)
+	if err != nil {
+		return err
+	}
+	tx.queued = append(tx.queued, queue)
+	tx.recordWrites(s, elements, keys, olds, nexts)
+	return nil
+}
+
+// recordWrites 将已加入事务的写入记录到 tx.pending，未写入的元素（olds 和 nexts 均无效）被跳过
+func (tx *Tx) recordWrites(s *schema, elements []reflect.Value, keys []string, olds, nexts []reflect.Value) {
+	for i, key := range keys {
+		if olds[i].IsValid() || nexts[i].IsValid() {
+			tx.pending.record(tx.db, s, key, tx.db.member(s, elements[i]), olds[i], nexts[i])
+		}
+	}
+}
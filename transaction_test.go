@@ -0,0 +1,180 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Inventory struct {
+	SKU   string `grm:"primaryKey"`
+	Stock int
+}
+
+type Purchase struct {
+	ID      uint
+	Product string `grm:"index"`
+}
+
+// 测试事务中不同模型的写入一起提交
+func TestTransaction(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	ctx := context.Background()
+	assert.NoError(t, db.Set(&Inventory{SKU: "apple", Stock: 3}))
+
+	err := db.Transaction(ctx, func(tx *Tx) error {
+		item := Inventory{SKU: "apple"}
+		if err := tx.Get(&item); err != nil {
+			return err
+		}
+		item.Stock--
+		if err := tx.Set(&item); err != nil {
+			return err
+		}
+		return tx.Set(&Purchase{ID: 1, Product: "apple"})
+	}, WithWatchReads())
+	assert.NoError(t, err)
+
+	item := Inventory{SKU: "apple"}
+	assert.NoError(t, db.Get(&item))
+	assert.Equal(t, 2, item.Stock)
+	members, _ := s.Members("grm:idx:purchases:product:apple")
+	assert.Equal(t, []string{"1"}, members)
+
+	// 删除同样在事务中提交
+	err = db.Transaction(ctx, func(tx *Tx) error {
+		if err := tx.Delete(&Purchase{ID: 1}); err != nil {
+			return err
+		}
+		return tx.Delete(&Inventory{SKU: "apple"})
+	})
+	assert.NoError(t, err)
+	assert.False(t, s.Exists("grm:inventories:apple"))
+	assert.False(t, s.Exists("grm:purchases:1"))
+	assert.False(t, s.Exists("grm:idx:purchases:product:apple"))
+}
+
+// 测试回调返回错误时放弃所有写入
+func TestTransactionRollback(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	errOutOfStock := errors.New("out of stock")
+
+	err := db.Transaction(context.Background(), func(tx *Tx) error {
+		if err := tx.Set(&Purchase{ID: 1, Product: "apple"}); err != nil {
+			return err
+		}
+		if err := tx.Set(&Inventory{SKU: "apple"}); err != nil {
+			return err
+		}
+		return errOutOfStock
+	})
+	assert.ErrorIs(t, err, errOutOfStock)
+	assert.Empty(t, s.Keys())
+}
+
+// 测试 WithWatchReads 在读取的 Key 被修改时重新执行事务
+func TestTransactionWatchReads(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	ctx := context.Background()
+	assert.NoError(t, db.Set(&Inventory{SKU: "apple", Stock: 3}))
+
+	calls := 0
+	err := db.Transaction(ctx, func(tx *Tx) error {
+		calls++
+		item := Inventory{SKU: "apple"}
+		if err := tx.Get(&item); err != nil {
+			return err
+		}
+		if calls == 1 {
+			// 模拟并发写入
+			assert.NoError(t, db.Set(&Inventory{SKU: "apple", Stock: 10}))
+		}
+		item.Stock--
+		return tx.Set(&item)
+	}, WithWatchReads())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	item := Inventory{SKU: "apple"}
+	assert.NoError(t, db.Get(&item))
+	assert.Equal(t, 9, item.Stock)
+}
+
+// 测试同一事务中多次写入同一记录时，后面的写入在前面的写入之上维护索引
+func TestTransactionRewrite(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	ctx := context.Background()
+
+	err := db.Transaction(ctx, func(tx *Tx) error {
+		if err := tx.Set(&Purchase{ID: 1, Product: "apple"}); err != nil {
+			return err
+		}
+		return tx.Set(&Purchase{ID: 1, Product: "pear"})
+	})
+	assert.NoError(t, err)
+	assert.False(t, s.Exists("grm:idx:purchases:product:apple"))
+	members, _ := s.Members("grm:idx:purchases:product:pear")
+	assert.Equal(t, []string{"1"}, members)
+
+	var found []Purchase
+	assert.NoError(t, db.FindBy(&found, "Product", "apple"))
+	assert.Empty(t, found)
+
+	// 写入后删除，索引同样被清理
+	err = db.Transaction(ctx, func(tx *Tx) error {
+		if err := tx.Set(&Purchase{ID: 2, Product: "plum"}); err != nil {
+			return err
+		}
+		return tx.Delete(&Purchase{ID: 2})
+	})
+	assert.NoError(t, err)
+	assert.False(t, s.Exists("grm:purchases:2"))
+	assert.False(t, s.Exists("grm:idx:purchases:product:plum"))
+}
+
+// 测试同一事务中的多次写入共同遵守唯一约束
+func TestTransactionUnique(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+	ctx := context.Background()
+
+	err := db.Transaction(ctx, func(tx *Tx) error {
+		if err := tx.Set(&Account{ID: 1, Email: "dup@example.com"}); err != nil {
+			return err
+		}
+		return tx.Set(&Account{ID: 2, Email: "dup@example.com"})
+	})
+	assert.ErrorIs(t, err, ErrUniqueViolation)
+	assert.False(t, s.Exists("grm:accounts:1"))
+	assert.False(t, s.Exists("grm:accounts:2"))
+
+	// 同一事务中先释放的值可以被其他记录占用
+	assert.NoError(t, db.Set(&Account{ID: 1, Email: "old@example.com"}))
+	err = db.Transaction(ctx, func(tx *Tx) error {
+		if err := tx.Set(&Account{ID: 1, Email: "new@example.com"}); err != nil {
+			return err
+		}
+		return tx.Set(&Account{ID: 2, Email: "old@example.com"})
+	})
+	assert.NoError(t, err)
+	holder, _ := s.Get("grm:uniq:accounts:email:old@example.com")
+	assert.Equal(t, "2", holder)
+	holder, _ = s.Get("grm:uniq:accounts:email:new@example.com")
+	assert.Equal(t, "1", holder)
+}
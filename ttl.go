@@ -0,0 +1,96 @@
+package grm
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// SlidingExpirer 由模型实现，声明 Get 读取成功后刷新的过期时间（滑动过期），优先于 touch 标签
+//
+//	func (Session) SlidingExpiration() time.Duration { return 30 * time.Minute }
+type SlidingExpirer interface {
+	SlidingExpiration() time.Duration
+}
+
+// Expirer 由模型实现，声明模型的默认过期时间，优先于 ttl 标签
+//
+//	func (Session) Expiration() time.Duration { return 30 * time.Minute }
+type Expirer interface {
+	Expiration() time.Duration
+}
+
+// parseTTL 解析 `grm:"ttl=10m"` 标签的值
+func parseTTL(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return d, nil
+}
+
+// ttlFor 返回 Set 使用的过期时间：通过 WithTTL 指定的优先，其次为模型的默认过期时间。
+// 部分更新（Select、Updates）未指定时以及使用 WithKeepTTL 时保留记录原有的过期时间
+func (db *DB) ttlFor(s *schema, cfg *setConfig) time.Duration {
+	if cfg.keepTTL {
+		return 0
+	}
+	if cfg.ttlSet || db.partial() {
+		return cfg.ttl
+	}
+	return s.TTL
+}
+
+// touchFor 返回 Get 读取成功后刷新的过期时间，通过 WithTouch 指定的优先，其次为模型的声明
+func touchFor(s *schema, cfg *getConfig) time.Duration {
+	if cfg.touchSet {
+		return cfg.touch
+	}
+	return s.Touch
+}
+
+// touch 刷新读取成功的记录的过期时间，failed 为读取失败的 Key。在事务中执行时加入事务，提交时刷新
+func (db *DB) touch(stmt *Statement, failed map[string]error) error {
+	ctx := stmt.Context
+	queue := func(pipe redis.Pipeliner) error {
+		for _, key := range stmt.Keys {
+			if failed[key] == nil {
+				pipe.PExpire(ctx, key, db.expiration(stmt.TTL))
+			}
+		}
+		return nil
+	}
+
+	if stmt.tx != nil {
+		stmt.tx.queued = append(stmt.tx.queued, queue)
+		return nil
+	}
+	_, err := db.client.Pipelined(ctx, queue)
+	return err
+}
+
+// expiration 为每个 Key 计算实际的过期时间，配置了 WithTTLJitter 时在 ttl 上随机延长
+func (db *DB) expiration(ttl time.Duration) time.Duration {
+	if ttl <= 0 || db.ttlJitter <= 0 {
+		return ttl
+	}
+	return ttl + time.Duration(float64(ttl)*db.ttlJitter/100*rand.Float64())
+}
+
+// setArgs 返回 String 模式下写入单个 Key 的 SET 参数，mode 为 "NX"、"XX" 或空
+func (db *DB) setArgs(cfg *setConfig, mode string) redis.SetArgs {
+	return redis.SetArgs{Mode: mode, TTL: db.expiration(cfg.ttl), KeepTTL: cfg.keepTTL}
+}
+
+// hashTTL 返回传给 Hash 写入脚本的过期时间（毫秒），-1 表示保留原有的过期时间
+func (db *DB) hashTTL(cfg *setConfig) int64 {
+	if cfg.keepTTL {
+		return -1
+	}
+	return db.expiration(cfg.ttl).Milliseconds()
+}
@@ -0,0 +1,123 @@
+package grm
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type CacheEntry struct {
+	ID    uint `grm:"ttl=10m"`
+	Value string
+}
+
+type LoginToken struct {
+	ID    string
+	Token string
+}
+
+func (LoginToken) Expiration() time.Duration { return time.Hour }
+
+type WebSession struct {
+	ID   string `grm:"ttl=30m,touch=30m"`
+	User string
+}
+
+// 测试模型的默认过期时间，以及调用时通过 WithTTL 覆盖
+func TestDefaultTTL(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	assert.NoError(t, db.Set(&CacheEntry{ID: 1, Value: "a"}))
+	assert.Equal(t, 10*time.Minute, s.TTL("grm:cache_entries:1"))
+
+	assert.NoError(t, db.Set(&LoginToken{ID: "s1", Token: "t"}))
+	assert.Equal(t, time.Hour, s.TTL("grm:login_tokens:s1"))
+
+	assert.NoError(t, db.Set(&CacheEntry{ID: 2, Value: "b"}, WithTTL(time.Minute)))
+	assert.Equal(t, time.Minute, s.TTL("grm:cache_entries:2"))
+
+	// WithTTL(0) 表示不过期
+	assert.NoError(t, db.Set(&CacheEntry{ID: 3, Value: "c"}, WithTTL(0)))
+	assert.Equal(t, time.Duration(0), s.TTL("grm:cache_entries:3"))
+
+	// 部分更新保留原有的过期时间
+	s.FastForward(time.Minute)
+	assert.NoError(t, db.Updates(&CacheEntry{ID: 1}, map[string]interface{}{"Value": "z"}))
+	assert.Equal(t, 9*time.Minute, s.TTL("grm:cache_entries:1"))
+}
+
+// 测试过期时间的随机抖动
+func TestTTLJitter(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()}, WithTTLJitter(50))
+
+	entries := make([]CacheEntry, 20)
+	for i := range entries {
+		entries[i] = CacheEntry{ID: uint(i + 1)}
+	}
+	assert.NoError(t, db.Set(&entries))
+
+	ttls := make(map[time.Duration]bool)
+	for i := range entries {
+		key, _ := db.getKey(&entries[i])
+		ttl := s.TTL(key)
+		assert.GreaterOrEqual(t, ttl, 10*time.Minute)
+		assert.Less(t, ttl, 15*time.Minute)
+		ttls[ttl] = true
+	}
+	assert.Greater(t, len(ttls), 1)
+}
+
+// 测试 Get 读取成功后刷新过期时间
+func TestTouch(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	assert.NoError(t, db.Set(&[]WebSession{{ID: "a", User: "u1"}, {ID: "b", User: "u2"}}))
+	s.FastForward(20 * time.Minute)
+
+	// 模型声明的滑动过期
+	assert.NoError(t, db.Get(&WebSession{ID: "a"}))
+	assert.Equal(t, 30*time.Minute, s.TTL("grm:web_sessions:a"))
+	assert.Equal(t, 10*time.Minute, s.TTL("grm:web_sessions:b"))
+
+	// WithTouch 覆盖模型的声明，不存在的记录不会被刷新
+	batch := []WebSession{{ID: "b"}, {ID: "c"}}
+	assert.ErrorIs(t, db.Get(&batch, WithTouch(time.Hour)), ErrNotFound)
+	assert.Equal(t, time.Hour, s.TTL("grm:web_sessions:b"))
+	assert.False(t, s.Exists("grm:web_sessions:c"))
+
+	s.FastForward(time.Minute)
+	assert.NoError(t, db.Get(&WebSession{ID: "a"}, WithTouch(0)))
+	assert.Equal(t, 29*time.Minute, s.TTL("grm:web_sessions:a"))
+
+	// 没有声明的模型默认不刷新
+	assert.NoError(t, db.Set(&CacheEntry{ID: 1}, WithTTL(time.Minute)))
+	assert.NoError(t, db.Get(&CacheEntry{ID: 1}))
+	assert.Equal(t, time.Minute, s.TTL("grm:cache_entries:1"))
+
+	// 事务中的刷新在提交时进行
+	err := db.Transaction(db.getContext(), func(tx *Tx) error {
+		return tx.Get(&CacheEntry{ID: 1}, WithTouch(time.Hour))
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, s.TTL("grm:cache_entries:1"))
+}
+
+// 测试无效的 ttl 标签
+func TestInvalidTTLTag(t *testing.T) {
+	type BadTTL struct {
+		ID uint `grm:"ttl=soon"`
+	}
+	_, err := parseSchema(reflect.TypeOf(BadTTL{}))
+	assert.ErrorContains(t, err, "invalid ttl")
+}
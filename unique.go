@@ -0,0 +1,154 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUniqueViolation 表示写入的值已被其他记录占用，具体的字段和记录见 UniqueError
+var ErrUniqueViolation = errors.New("unique constraint violation")
+
+// UniqueError 描述一次唯一约束冲突：模型 Model 的字段 Field 的值 Value 已被主键为 ID 的记录占用
+type UniqueError struct {
+	Model string
+	Field string
+	Value string
+	ID    string
+}
+
+func (e *UniqueError) Error() string {
+	return fmt.Sprintf("unique constraint violation: %s.%s %q is taken by %s", e.Model, e.Field, e.Value, e.ID)
+}
+
+func (e *UniqueError) Unwrap() error {
+	return ErrUniqueViolation
+}
+
+// uniqueKey 返回唯一字段值的占用 Key，如 "grm:uniq:users:email:a@b.c"，值为占用该值的记录的主键
+func (db *DB) uniqueKey(s *schema, f *field, value string) string {
+	return metaKey(db.namingStrategy, s, "uniq", f.Column, value)
+}
+
+// uniqueFields 返回本次写入涉及的唯一字段，fields 为空时为全部唯一字段
+func uniqueFields(s *schema, fields []*field) []*field {
+	if fields == nil {
+		return s.Uniques
+	}
+
+	var result []*field
+	for _, f := range fields {
+		if _, ok := f.Tags["unique"]; ok {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// uniqueKeys 返回 elements 将要占用的 Key，需要在读取前 WATCH
+func (db *DB) uniqueKeys(s *schema, elements []reflect.Value, fields []*field) []string {
+	var keys []string
+	for _, f := range uniqueFields(s, fields) {
+		for _, elem := range elements {
+			if value, ok := db.indexValue(elem, f); ok {
+				keys = append(keys, db.uniqueKey(s, f, value))
+			}
+		}
+	}
+	return keys
+}
+
+// checkUnique 在 WATCH 事务中检查 elements 的唯一字段是否已被其他记录占用，返回冲突的 Key 及原因。
+// 占用者的记录已不存在（如因 TTL 过期）时，其占用视为无效；同一批次中后出现的重复值同样视为冲突。
+// skip 中的元素已经失败，不参与检查；pending 中尚未提交的占用和释放优先于已存储的占用，不在事务中时为 nil
+func (db *DB) checkUnique(ctx context.Context, tx *redis.Tx, s *schema, elements []reflect.Value, keys []string, fields []*field, skip map[string]error, pending *pendingWrites) (map[string]error, error) {
+	type claim struct {
+		f     *field
+		value string
+		key   string
+	}
+
+	uniques := uniqueFields(s, fields)
+	claims := make([][]claim, len(elements))
+	var claimKeys []string
+	for i, elem := range elements {
+		if skip[keys[i]] != nil {
+			continue
+		}
+		for _, f := range uniques {
+			if value, ok := db.indexValue(elem, f); ok {
+				key := db.uniqueKey(s, f, value)
+				claims[i] = append(claims[i], claim{f: f, value: value, key: key})
+				claimKeys = append(claimKeys, key)
+			}
+		}
+	}
+	if len(claimKeys) == 0 {
+		return nil, nil
+	}
+
+	values, err := tx.MGet(ctx, claimKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// 检查占用者的记录是否仍然存在，存在时才视为有效占用
+	holders := make(map[string]string)
+	var holderClaims, holderKeys []string
+	for i, value := range values {
+		if holder, ok := pending.claim(claimKeys[i]); ok {
+			if holder != "" {
+				holders[claimKeys[i]] = holder
+			}
+			continue
+		}
+		if member, ok := value.(string); ok {
+			holders[claimKeys[i]] = member
+			holderClaims = append(holderClaims, claimKeys[i])
+			holderKeys = append(holderKeys, keyPrefix(db.namingStrategy, s)+member)
+		}
+	}
+	if len(holderKeys) > 0 {
+		if err := tx.Watch(ctx, holderKeys...).Err(); err != nil {
+			return nil, err
+		}
+		cmds := make([]*redis.IntCmd, 0, len(holderKeys))
+		_, err := tx.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for _, key := range holderKeys {
+				cmds = append(cmds, pipe.Exists(ctx, key))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i, cmd := range cmds {
+			if cmd.Val() == 0 {
+				delete(holders, holderClaims[i])
+			}
+		}
+	}
+
+	failed := make(map[string]error)
+	for i, elem := range elements {
+		member := db.member(s, elem)
+		var violation error
+		for _, c := range claims[i] {
+			if holder, ok := holders[c.key]; ok && holder != member {
+				violation = &UniqueError{Model: s.Name, Field: c.f.Name, Value: c.value, ID: holder}
+				break
+			}
+		}
+		if violation != nil {
+			failed[keys[i]] = violation
+			continue
+		}
+		for _, c := range claims[i] {
+			holders[c.key] = member
+		}
+	}
+	return failed, nil
+}
@@ -0,0 +1,93 @@
+package grm
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Account struct {
+	ID       uint
+	Email    string  `grm:"unique"`
+	Username *string `grm:"unique"`
+}
+
+// 测试唯一字段的占用、冲突和释放
+func TestUnique(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		assert.NoError(t, db.Set(&Account{ID: 1, Email: "a@b.c"}))
+		owner, _ := s.Get("grm:uniq:accounts:email:a@b.c")
+		assert.Equal(t, "1", owner)
+
+		// 重复写入自身不冲突
+		assert.NoError(t, db.Set(&Account{ID: 1, Email: "a@b.c"}))
+
+		err := db.Set(&Account{ID: 2, Email: "a@b.c"})
+		assert.True(t, errors.Is(err, ErrUniqueViolation))
+		var uniqueErr *UniqueError
+		assert.True(t, errors.As(err, &uniqueErr))
+		assert.Equal(t, "Email", uniqueErr.Field)
+		assert.Equal(t, "1", uniqueErr.ID)
+		assert.False(t, s.Exists("grm:accounts:2"))
+
+		// 修改后释放旧值
+		assert.NoError(t, db.Set(&Account{ID: 1, Email: "new@b.c"}))
+		assert.False(t, s.Exists("grm:uniq:accounts:email:a@b.c"))
+		assert.NoError(t, db.Set(&Account{ID: 2, Email: "a@b.c"}))
+
+		// 部分更新同样检查唯一约束
+		err = db.Updates(&Account{ID: 2}, map[string]interface{}{"Email": "new@b.c"})
+		assert.True(t, errors.Is(err, ErrUniqueViolation))
+
+		// 删除后释放占用
+		assert.NoError(t, db.Delete(&Account{ID: 1}))
+		assert.False(t, s.Exists("grm:uniq:accounts:email:new@b.c"))
+		assert.NoError(t, db.Updates(&Account{ID: 2}, map[string]interface{}{"Email": "new@b.c"}))
+		s.Close()
+	}
+}
+
+// 测试批量写入中的冲突只影响对应的元素
+func TestUniqueBatch(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	name := "alice"
+	accounts := []Account{
+		{ID: 1, Email: "a@b.c", Username: &name},
+		{ID: 2, Email: "d@e.f", Username: &name},
+		{ID: 3, Email: "g@h.i"},
+	}
+	err := db.Set(&accounts)
+	var partial *PartialError
+	assert.True(t, errors.As(err, &partial))
+	assert.Len(t, partial.Errors, 1)
+	assert.True(t, errors.Is(partial.Errors["grm:accounts:2"], ErrUniqueViolation))
+
+	assert.True(t, s.Exists("grm:accounts:1"))
+	assert.False(t, s.Exists("grm:accounts:2"))
+	assert.True(t, s.Exists("grm:accounts:3"))
+	assert.False(t, s.Exists("grm:uniq:accounts:email:d@e.f"))
+}
+
+// 测试占用者的记录过期后，其占用不再生效
+func TestUniqueExpiredHolder(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	assert.NoError(t, db.Set(&Account{ID: 1, Email: "a@b.c"}, WithTTL(time.Minute)))
+	s.FastForward(2 * time.Minute)
+
+	assert.NoError(t, db.Set(&Account{ID: 2, Email: "a@b.c"}))
+	owner, _ := s.Get("grm:uniq:accounts:email:a@b.c")
+	assert.Equal(t, "2", owner)
+}
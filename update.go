@@ -0,0 +1,195 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// updateHashScript 仅在 Hash 存在时更新部分字段
+// ARGV: [ttl(毫秒), 待删除字段数 n, 待删除字段..., field1, value1, ...]
+var updateHashScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local n = tonumber(ARGV[2])
+for i = 3, n + 2 do
+	redis.call('HDEL', KEYS[1], ARGV[i])
+end
+if #ARGV > n + 2 then
+	redis.call('HSET', KEYS[1], unpack(ARGV, n + 3))
+end
+local ttl = tonumber(ARGV[1])
+if ttl > 0 then
+	redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// Select 返回只写入指定字段的会话，字段可以是 Go 字段名或 Hash field 名。
+// 至少需要一个字段，且不能包含主键，否则写入时返回错误
+//
+//	db.Select("Name", "Age").Set(&user)
+func (db *DB) Select(fields ...string) *DB {
+	tx := *db
+	tx.selects = append([]string{}, fields...)
+	return &tx
+}
+
+// partial 判断会话是否为通过 Select 设置的部分更新
+func (db *DB) partial() bool {
+	return db.selects != nil
+}
+
+// Updates 将 values 写入模型并只更新这些字段，键为 Go 字段名或 Hash field 名。
+// values 不能为空，也不能包含主键
+//
+//	db.Updates(&user, map[string]interface{}{"Name": "Bob", "Age": 31})
+func (db *DB) Updates(input interface{}, values map[string]interface{}) error {
+	if len(values) == 0 {
+		return errors.New("Updates requires at least one field")
+	}
+
+	elements, err := processBatch(input)
+	if err != nil || len(elements) == 0 {
+		return err
+	}
+
+	s, err := parseSchema(elements[0].Type())
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	// 先检查所有字段，避免出错时模型已被部分修改
+	fields, err := db.Select(names...).selectedFields(s)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		value, ok := values[f.Name]
+		if !ok {
+			value = values[f.Column]
+		}
+		for _, elem := range elements {
+			if err := assignValue(elem.FieldByIndex(f.Index), value); err != nil {
+				return fmt.Errorf("field %s: %v", f.Name, err)
+			}
+		}
+	}
+
+	return db.Select(names...).Set(input)
+}
+
+// selectedFields 将 Select 的字段名解析为字段，主键不可更新（UpdatedAt 由 grm:timestamps 回调加入）
+func (db *DB) selectedFields(s *schema) ([]*field, error) {
+	if len(db.selects) == 0 {
+		return nil, errors.New("Select requires at least one field")
+	}
+
+	fields := make([]*field, 0, len(db.selects))
+	seen := make(map[*field]bool)
+	for _, name := range db.selects {
+		f := s.lookUpFieldOrColumn(name)
+		if f == nil {
+			return nil, fmt.Errorf("model %s has no field %q", s.Name, name)
+		}
+		if f.PrimaryKey {
+			return nil, fmt.Errorf("primary key field %s of model %s cannot be updated", f.Name, s.Name)
+		}
+		if seen[f] {
+			continue
+		}
+		seen[f] = true
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+// setPartial 只写入 Select 指定的字段，Key 不存在时不会创建记录。
+// String 模式下的值由任意 Serializer 编码，无法在 Lua 中合并，因此在 WATCH 事务中合并，冲突时按 defaultRetry 退避重试
+func (db *DB) setPartial(ctx context.Context, s *schema, elements []reflect.Value, keys []string, cfg *setConfig) error {
+	fields, err := db.selectedFields(s)
+	if err != nil {
+		return err
+	}
+
+	// Hash 模式下无需读取旧值时直接用 Lua 脚本更新，否则在 WATCH 事务中合并后写回（已软删除的记录需要视为不存在，WithAtomic 需要先检查所有元素）
+	if db.storageMode(s) == StorageHash && !s.needsWatch() && s.DeletedAt == nil && !cfg.atomic {
+		return db.setPartialHash(ctx, s, elements, keys, fields, cfg)
+	}
+	return db.saveWatched(ctx, s, elements, keys, fields, cfg)
+}
+
+// setPartialHash 使用 Lua 脚本在服务端原子地更新 Hash 中的部分字段
+func (db *DB) setPartialHash(ctx context.Context, s *schema, elements []reflect.Value, keys []string, fields []*field, cfg *setConfig) error {
+	pipe := db.client.Pipeline()
+	cmds := make([]*redis.Cmd, 0, len(elements))
+	for i, elem := range elements {
+		var deletes, sets []interface{}
+		for _, f := range fields {
+			fv := elem.FieldByIndex(f.Index)
+			if fv.Kind() == reflect.Ptr && fv.IsNil() {
+				deletes = append(deletes, f.Column)
+				continue
+			}
+
+			data, err := db.encodeField(fv)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", f.Name, err)
+			}
+			sets = append(sets, f.Column, data)
+		}
+
+		args := append([]interface{}{db.expiration(cfg.ttl).Milliseconds(), len(deletes)}, deletes...)
+		args = append(args, sets...)
+		cmds = append(cmds, updateHashScript.Eval(ctx, pipe, []string{keys[i]}, args...))
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	errors := make(map[string]error)
+	for i, cmd := range cmds {
+		if n, _ := cmd.Int(); n == 0 {
+			errors[keys[i]] = ErrNotFound
+		}
+	}
+	if len(errors) > 0 {
+		return &PartialError{Errors: errors}
+	}
+	return nil
+}
+
+// assignValue 将任意值赋给字段，必要时进行类型转换
+func assignValue(fv reflect.Value, value interface{}) error {
+	if value == nil {
+		fv.Set(reflect.Zero(fv.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch {
+	case v.Type().AssignableTo(fv.Type()):
+		fv.Set(v)
+	case fv.Kind() == reflect.Ptr && v.Type().AssignableTo(fv.Type().Elem()):
+		p := reflect.New(fv.Type().Elem())
+		p.Elem().Set(v)
+		fv.Set(p)
+	case v.Type().ConvertibleTo(fv.Type()) && (fv.Kind() != reflect.String || v.Kind() == reflect.String):
+		fv.Set(v.Convert(fv.Type()))
+	default:
+		return fmt.Errorf("cannot assign %T to %s", value, fv.Type())
+	}
+	return nil
+}
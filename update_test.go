@@ -0,0 +1,105 @@
+package grm
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Member struct {
+	ID        uint
+	Name      string
+	Age       int
+	Email     *string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// 测试 Select 只写入指定字段
+func TestSelectSet(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		original := Member{ID: 1, Name: "Alice", Age: 30}
+		assert.NoError(t, db.Set(&original))
+
+		// 另一个服务只修改 Age，不会覆盖 Name
+		partial := Member{ID: 1, Age: 31}
+		assert.NoError(t, db.Select("Age").Set(&partial))
+		assert.True(t, partial.UpdatedAt.After(original.UpdatedAt) || partial.UpdatedAt.Equal(original.UpdatedAt))
+		assert.True(t, partial.CreatedAt.IsZero())
+
+		fetched := Member{ID: 1}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "Alice", fetched.Name)
+		assert.Equal(t, 31, fetched.Age)
+		assert.True(t, original.CreatedAt.Equal(fetched.CreatedAt))
+		assert.True(t, partial.UpdatedAt.Equal(fetched.UpdatedAt))
+
+		// 不存在的记录不会被创建
+		err := db.Select("Age").Set(&Member{ID: 2, Age: 1})
+		assert.ErrorIs(t, err.(*PartialError).Errors["grm:members:2"], ErrNotFound)
+		assert.False(t, s.Exists("grm:members:2"))
+
+		// 未知字段、空字段列表和主键报错，且不写入任何内容
+		assert.Error(t, db.Select("Unknown").Set(&partial))
+		assert.EqualError(t, db.Select().Set(&Member{ID: 3, Age: 1}), "Select requires at least one field")
+		assert.ErrorContains(t, db.Select("ID", "Age").Set(&partial), "primary key")
+		assert.False(t, s.Exists("grm:members:3"))
+		s.Close()
+	}
+}
+
+// 测试 Updates 更新字段并同步到模型
+func TestUpdates(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		email := "alice@example.com"
+		user := Member{ID: 1, Name: "Alice", Age: 30, Email: &email}
+		assert.NoError(t, db.Set(&user))
+
+		assert.NoError(t, db.Updates(&user, map[string]interface{}{"Name": "Bob", "age": 31, "Email": nil}))
+		assert.Equal(t, "Bob", user.Name)
+		assert.Equal(t, 31, user.Age)
+		assert.Nil(t, user.Email)
+
+		fetched := Member{ID: 1}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "Bob", fetched.Name)
+		assert.Equal(t, 31, fetched.Age)
+		assert.Nil(t, fetched.Email)
+
+		assert.Error(t, db.Updates(&user, map[string]interface{}{"Age": "old"}))
+
+		// 空的 values 和主键字段报错，不会创建或改写记录
+		missing := Member{ID: 7}
+		assert.Error(t, db.Updates(&missing, map[string]interface{}{}))
+		assert.False(t, s.Exists("grm:members:7"))
+		assert.ErrorContains(t, db.Updates(&user, map[string]interface{}{"ID": 5, "Name": "Eve"}), "primary key")
+		assert.Equal(t, uint(1), user.ID)
+		assert.False(t, s.Exists("grm:members:5"))
+		s.Close()
+	}
+}
+
+// 测试部分更新时保留原有 TTL
+func TestSelectKeepsTTL(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		user := Member{ID: 1, Name: "Alice"}
+		assert.NoError(t, db.Set(&user, WithTTL(time.Minute)))
+
+		assert.NoError(t, db.Updates(&user, map[string]interface{}{"Name": "Bob"}))
+		assert.Equal(t, time.Minute, s.TTL("grm:members:1"))
+
+		assert.NoError(t, db.Select("Name").Set(&user, WithTTL(time.Hour)))
+		assert.Equal(t, time.Hour, s.TTL("grm:members:1"))
+		s.Close()
+	}
+}
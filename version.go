@@ -0,0 +1,55 @@
+package grm
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ErrStaleObject 表示记录已被其他写入修改，内存中的版本号与已存储的不一致
+var ErrStaleObject = errors.New("stale object")
+
+// isVersionType 判断类型能否作为版本号
+func isVersionType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// versionOf 返回版本号字段的值
+func versionOf(fv reflect.Value) uint64 {
+	switch fv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fv.Uint()
+	}
+	return uint64(fv.Int())
+}
+
+// incrementVersion 将版本号字段加一
+func incrementVersion(fv reflect.Value) {
+	switch fv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fv.SetUint(fv.Uint() + 1)
+	default:
+		fv.SetInt(fv.Int() + 1)
+	}
+}
+
+// checkVersion 检查 elem 的版本号是否与已存储的记录一致，old 无效表示记录不存在，此时版本号应为 0
+func checkVersion(s *schema, elem, old reflect.Value) error {
+	if s.Version == nil {
+		return nil
+	}
+
+	var stored uint64
+	if old.IsValid() {
+		stored = versionOf(old.FieldByIndex(s.Version.Index))
+	}
+	if current := versionOf(elem.FieldByIndex(s.Version.Index)); current != stored {
+		return fmt.Errorf("%w: %s has version %d, stored version is %d", ErrStaleObject, s.Name, current, stored)
+	}
+	return nil
+}
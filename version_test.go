@@ -0,0 +1,84 @@
+package grm
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Document struct {
+	ID      uint
+	Title   string
+	Version int `grm:"version"`
+}
+
+// 测试版本号一致时写入成功并递增，不一致时返回 ErrStaleObject
+func TestOptimisticLocking(t *testing.T) {
+	for _, mode := range []StorageMode{StorageString, StorageHash} {
+		s := setupTestRedis()
+		db, _ := Open(&Options{Addr: s.Addr()}, WithStorageMode(mode))
+
+		doc := Document{ID: 1, Title: "draft"}
+		assert.NoError(t, db.Set(&doc))
+		assert.Equal(t, 1, doc.Version)
+
+		// 另一个写入者读取同一版本
+		other := Document{ID: 1}
+		assert.NoError(t, db.Get(&other))
+		assert.Equal(t, 1, other.Version)
+
+		doc.Title = "final"
+		assert.NoError(t, db.Set(&doc))
+		assert.Equal(t, 2, doc.Version)
+
+		other.Title = "overwrite"
+		err := db.Set(&other)
+		assert.True(t, errors.Is(err, ErrStaleObject))
+		assert.Equal(t, 1, other.Version)
+
+		fetched := Document{ID: 1}
+		assert.NoError(t, db.Get(&fetched))
+		assert.Equal(t, "final", fetched.Title)
+		assert.Equal(t, 2, fetched.Version)
+
+		// 部分更新同样检查并递增版本号
+		assert.True(t, errors.Is(db.Updates(&Document{ID: 1}, map[string]interface{}{"Title": "x"}), ErrStaleObject))
+		assert.NoError(t, db.Updates(&fetched, map[string]interface{}{"Title": "edited"}))
+		assert.Equal(t, 3, fetched.Version)
+
+		// 记录不存在时版本号必须为 0
+		assert.True(t, errors.Is(db.Set(&Document{ID: 2, Version: 5}), ErrStaleObject))
+		s.Close()
+	}
+}
+
+// 测试批量写入只拒绝版本号冲突的元素
+func TestOptimisticLockingBatch(t *testing.T) {
+	s := setupTestRedis()
+	defer s.Close()
+
+	db, _ := Open(&Options{Addr: s.Addr()})
+
+	docs := []Document{{ID: 1}, {ID: 2}}
+	assert.NoError(t, db.Set(&docs))
+
+	docs[0].Version = 0
+	err := db.Set(&docs)
+	var partial *PartialError
+	assert.True(t, errors.As(err, &partial))
+	assert.Len(t, partial.Errors, 1)
+	assert.True(t, errors.Is(partial.Errors["grm:documents:1"], ErrStaleObject))
+	assert.Equal(t, []int{0, 2}, []int{docs[0].Version, docs[1].Version})
+}
+
+// 测试版本号字段必须是整数
+func TestVersionFieldType(t *testing.T) {
+	type BadVersion struct {
+		ID      uint
+		Version string `grm:"version"`
+	}
+	_, err := parseSchema(reflect.TypeOf(BadVersion{}))
+	assert.ErrorContains(t, err, "must be an integer")
+}
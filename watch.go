@@ -0,0 +1,305 @@
+package grm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// maxWatchRetries 是 WATCH 事务因冲突失败后的最大重试次数
+const maxWatchRetries = 10
+
+// retryConfig 控制 WATCH 事务因冲突失败后的重试
+type retryConfig struct {
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// defaultRetry 是默认的重试配置：最多重试 10 次，等待时间从 1ms 开始每次翻倍，不超过 100ms
+var defaultRetry = retryConfig{maxRetries: maxWatchRetries, backoff: time.Millisecond, maxBackoff: 100 * time.Millisecond}
+
+// retry 执行 attempt，因 WATCH 的 Key 被修改而失败（redis.TxFailedErr）时等待后重试。
+// 等待时间指数增长并加入随机抖动，避免多个写入者同时重试
+func retry(ctx context.Context, cfg retryConfig, attempt func() error) error {
+	backoff := cfg.backoff
+	for i := 0; ; i++ {
+		err := attempt()
+		if !errors.Is(err, redis.TxFailedErr) || i >= cfg.maxRetries {
+			return err
+		}
+
+		wait := backoff
+		if wait > 0 {
+			wait = wait/2 + rand.N(wait/2+1)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		if backoff *= 2; backoff > cfg.maxBackoff {
+			backoff = cfg.maxBackoff
+		}
+	}
+}
+
+// watched 判断完整写入是否需要在 WATCH 事务中进行：模型需要读取已存储的记录，
+// 或按条件写入时带 DeletedAt（已软删除的记录需要视为不存在）或使用 WithAtomic（需要先检查所有元素）
+func watched(s *schema, cfg *setConfig) bool {
+	return s.needsWatch() || (cfg.mode != writeUpsert && (s.DeletedAt != nil || cfg.atomic))
+}
+
+// saveWatched 在 WATCH 事务中写入记录：先读取已存储的记录，再由 writeWatched 检查并写入。
+// fields 不为空时为部分更新，只写入这些字段，且不会创建不存在的记录
+func (db *DB) saveWatched(ctx context.Context, s *schema, elements []reflect.Value, keys []string, fields []*field, cfg *setConfig) error {
+	var failed map[string]error
+	txf := func(tx *redis.Tx) error {
+		failed = make(map[string]error)
+
+		stored := newElements(s, len(elements))
+		loadErrors, err := db.load(ctx, tx, s, stored, keys)
+		if err != nil {
+			return err
+		}
+
+		olds := make([]reflect.Value, len(elements))
+		for i, key := range keys {
+			err := loadErrors[key]
+			if err != nil && !errors.Is(err, ErrNotFound) {
+				failed[key] = err
+				continue
+			}
+			// 部分更新只更新已存在的记录
+			mode := cfg.mode
+			if fields != nil {
+				mode = writeUpdate
+			}
+			if err := modeError(mode, db.exists(s, stored[i], err)); err != nil {
+				failed[key] = err
+				continue
+			}
+			if err == nil {
+				olds[i] = stored[i]
+			}
+		}
+		return db.writeWatched(ctx, tx, s, elements, olds, keys, fields, cfg, failed)
+	}
+
+	if err := db.watch(ctx, txf, keys...); err != nil {
+		return err
+	}
+
+	if s.Version != nil {
+		for i, elem := range elements {
+			if failed[keys[i]] == nil {
+				incrementVersion(elem.FieldByIndex(s.Version.Index))
+			}
+		}
+	}
+	if len(failed) > 0 {
+		return &PartialError{Errors: failed}
+	}
+	return nil
+}
+
+// writeWatched 在已 WATCH 记录 Key 的事务中检查版本号和唯一约束，再在 MULTI/EXEC 中写入记录并维护索引。
+// olds 为已存储的记录，无效表示记录不存在；失败的元素记录在 failed 中并跳过，调用前已在 failed 中的元素同样跳过
+func (db *DB) writeWatched(ctx context.Context, tx *redis.Tx, s *schema, elements, olds []reflect.Value, keys []string, fields []*field, cfg *setConfig, failed map[string]error) error {
+	queue, _, err := db.prepareWrite(ctx, tx, s, elements, olds, keys, fields, cfg, failed, nil)
+	if err != nil {
+		return err
+	}
+	if cfg.atomic && len(failed) > 0 {
+		// WithAtomic 时任一元素失败则整个批次都不写入
+		for _, key := range keys {
+			if failed[key] == nil {
+				failed[key] = ErrBatchAborted
+			}
+		}
+		return nil
+	}
+	_, err = tx.TxPipelined(ctx, queue)
+	return err
+}
+
+// prepareWrite 完成 writeWatched 中 MULTI 之前的检查，返回将写入命令加入事务的函数，以及各元素写入后的记录（失败的元素无效）。
+// pending 为事务中尚未提交的写入，唯一约束检查以其为准，不在事务中时为 nil
+func (db *DB) prepareWrite(ctx context.Context, tx *redis.Tx, s *schema, elements, olds []reflect.Value, keys []string, fields []*field, cfg *setConfig, failed map[string]error, pending *pendingWrites) (func(redis.Pipeliner) error, []reflect.Value, error) {
+	writeFields := fields
+	if fields != nil && s.Version != nil {
+		writeFields = append(fields[:len(fields):len(fields)], s.Version)
+	}
+
+	for i, key := range keys {
+		if failed[key] != nil {
+			continue
+		}
+		if cfg.skipVersion {
+			continue
+		}
+		// 已软删除的记录视为不存在，重新创建时版本号从 0 开始
+		old := olds[i]
+		if old.IsValid() && !db.exists(s, old, nil) {
+			old = reflect.Value{}
+		}
+		if err := checkVersion(s, elements[i], old); err != nil {
+			failed[key] = err
+		}
+	}
+
+	// 占用 Key 在读取前 WATCH，保证检查和写入之间没有其他记录占用同一个值
+	if claimKeys := db.uniqueKeys(s, elements, fields); len(claimKeys) > 0 {
+		if err := tx.Watch(ctx, claimKeys...).Err(); err != nil {
+			return nil, nil, err
+		}
+	}
+	violations, err := db.checkUnique(ctx, tx, s, elements, keys, fields, failed, pending)
+	if err != nil {
+		return nil, nil, err
+	}
+	for key, err := range violations {
+		failed[key] = err
+	}
+
+	nexts := make([]reflect.Value, len(elements))
+	for i, elem := range elements {
+		if failed[keys[i]] != nil {
+			continue
+		}
+		// 部分更新在已存储的记录上合并选中的字段，完整写入使用 elem 的副本
+		next := reflect.New(s.Type).Elem()
+		if fields != nil {
+			next.Set(olds[i])
+			for _, f := range fields {
+				next.FieldByIndex(f.Index).Set(elem.FieldByIndex(f.Index))
+			}
+		} else {
+			next.Set(elem)
+		}
+		if s.Version != nil {
+			// 写入递增后的版本号，提交成功后才更新 elem，避免重试时版本号被重复递增
+			incrementVersion(next.FieldByIndex(s.Version.Index))
+		}
+		nexts[i] = next
+	}
+
+	return func(pipe redis.Pipeliner) error {
+		for i, elem := range elements {
+			key := keys[i]
+			if failed[key] != nil {
+				continue
+			}
+			if err := db.queueWrite(ctx, pipe, s, key, nexts[i], writeFields, cfg); err != nil {
+				return err
+			}
+			db.queueIndexes(ctx, pipe, s, db.member(s, elem), olds[i], nexts[i])
+		}
+		return nil
+	}, nexts, nil
+}
+
+// deleteWatched 在 WATCH 事务中读取已存储的记录，再在 MULTI/EXEC 中删除记录并清理索引
+func (db *DB) deleteWatched(ctx context.Context, s *schema, elements []reflect.Value, keys []string) error {
+	txf := func(tx *redis.Tx) error {
+		queue, _, err := db.prepareDelete(ctx, tx, s, elements, keys, nil)
+		if err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(ctx, queue)
+		return err
+	}
+
+	return db.watch(ctx, txf, keys...)
+}
+
+// prepareDelete 读取已存储的记录，返回将删除记录和清理索引的命令加入事务的函数，以及被删除的记录（不存在的记录无效）。
+// pending 为事务中尚未提交的写入，读取的记录以其为准，不在事务中时为 nil
+func (db *DB) prepareDelete(ctx context.Context, tx *redis.Tx, s *schema, elements []reflect.Value, keys []string, pending *pendingWrites) (func(redis.Pipeliner) error, []reflect.Value, error) {
+	stored := newElements(s, len(keys))
+	loadErrors, err := db.load(ctx, tx, s, stored, keys)
+	if err != nil {
+		return nil, nil, err
+	}
+	pending.apply(stored, keys, loadErrors)
+
+	olds := make([]reflect.Value, len(keys))
+	for i, key := range keys {
+		if loadErrors[key] == nil {
+			olds[i] = stored[i]
+		}
+	}
+
+	return func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, keys...)
+		for i := range keys {
+			db.queueIndexes(ctx, pipe, s, db.member(s, elements[i]), olds[i], reflect.Value{})
+		}
+		return nil
+	}, olds, nil
+}
+
+// queueWrite 将单个记录的写入命令加入事务。fields 不为空时只写入这些字段，此时以及使用 WithKeepTTL 时，
+// 未指定 TTL 则保留原有过期时间
+func (db *DB) queueWrite(ctx context.Context, pipe redis.Pipeliner, s *schema, key string, v reflect.Value, fields []*field, cfg *setConfig) error {
+	if db.storageMode(s) == StorageHash {
+		if fields == nil {
+			values, err := db.encodeHash(s, v)
+			if err != nil {
+				return err
+			}
+			queueReplaceHash(ctx, pipe, key, values, cfg)
+		} else {
+			for _, f := range fields {
+				fv := v.FieldByIndex(f.Index)
+				if fv.Kind() == reflect.Ptr && fv.IsNil() {
+					pipe.HDel(ctx, key, f.Column)
+					continue
+				}
+				data, err := db.encodeField(fv)
+				if err != nil {
+					return fmt.Errorf("field %s: %v", f.Name, err)
+				}
+				pipe.HSet(ctx, key, f.Column, data)
+			}
+		}
+		if cfg.ttl > 0 {
+			pipe.Expire(ctx, key, db.expiration(cfg.ttl))
+		} else if cfg.persist {
+			pipe.Persist(ctx, key)
+		}
+		return nil
+	}
+
+	data, err := db.serializer.Marshal(v.Addr().Interface())
+	if err != nil {
+		return err
+	}
+	if (fields != nil || cfg.keepTTL) && cfg.ttl == 0 && !cfg.persist {
+		pipe.SetArgs(ctx, key, data, redis.SetArgs{KeepTTL: true})
+	} else {
+		pipe.Set(ctx, key, data, db.expiration(cfg.ttl))
+	}
+	return nil
+}
+
+// watch 执行 WATCH 事务，冲突时按 defaultRetry 退避后重试，重试次数用尽时返回 redis.TxFailedErr
+func (db *DB) watch(ctx context.Context, fn func(*redis.Tx) error, keys ...string) error {
+	return retry(ctx, defaultRetry, func() error {
+		return db.client.Watch(ctx, fn, keys...)
+	})
+}
+
+// newElements 创建 n 个模型类型的零值，用于读取已存储的记录
+func newElements(s *schema, n int) []reflect.Value {
+	elements := make([]reflect.Value, n)
+	for i := range elements {
+		elements[i] = reflect.New(s.Type).Elem()
+	}
+	return elements
+}